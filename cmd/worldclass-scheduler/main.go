@@ -2,6 +2,8 @@ package main
 
 import (
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -13,16 +15,50 @@ const defaultConfigPath = "config.yaml"
 func main() {
 	var (
 		cfgPath      string
+		profileName  string
 		fetchShowAll bool
+		fetchGroup   string
 		scheduleLoop bool
+		scheduleDry  bool
+		dryRunWeeks  int
+		nextCount    int
+		outputFormat string
+		clubMatch    string
+		clubsWrite   bool
+		interestOpts worldclass.InterestsOptions
+		serveOpts    worldclass.ServeOptions
+		reportOpts   worldclass.ReportOptions
+		reportFile   string
+		diffOffline  bool
+		pauseOpts    worldclass.PauseOptions
+		pauseFrom    string
+		pauseUntil   string
+		logOpts      worldclass.LogOptions
+		logger       *slog.Logger
+		logCloser    io.Closer
 	)
 
 	rootCmd := &cobra.Command{
 		Use:   "worldclass-scheduler",
 		Short: "Automate fetching and booking of WorldClass classes",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			logger, logCloser, err = worldclass.NewLogger(logOpts)
+			if err != nil {
+				return err
+			}
+			slog.SetDefault(logger)
+			return nil
+		},
 	}
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
 	rootCmd.PersistentFlags().StringVar(&cfgPath, "config", envOrDefault("WORLDCLASS_CONFIG", defaultConfigPath), "path to configuration file")
+	rootCmd.PersistentFlags().StringVar(&logOpts.Level, "log-level", envOrDefault("WORLDCLASS_LOG_LEVEL", "info"), "log level: debug, info, warn or error")
+	rootCmd.PersistentFlags().StringVar(&logOpts.Format, "log-format", envOrDefault("WORLDCLASS_LOG_FORMAT", "text"), "log format: text or json")
+	rootCmd.PersistentFlags().StringVar(&logOpts.File, "log-file", envOrDefault("WORLDCLASS_LOG_FILE", ""), "write logs to this file instead of stdout")
+	rootCmd.PersistentFlags().IntVar(&logOpts.MaxSizeMB, "log-max-size", 10, "rotate the log file once it exceeds this many megabytes (0 disables rotation)")
+	rootCmd.PersistentFlags().IntVar(&logOpts.MaxBackups, "log-max-backups", 3, "number of rotated log files to keep")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", envOrDefault("WORLDCLASS_PROFILE", ""), "only act on the named account profile")
 
 	fetchCmd := &cobra.Command{
 		Use:   "fetch",
@@ -32,10 +68,11 @@ func main() {
 			if err != nil {
 				return err
 			}
-			return worldclass.RunFetch(cfg, worldclass.FetchOptions{ShowAll: fetchShowAll})
+			return worldclass.RunFetch(cfg, worldclass.FetchOptions{ShowAll: fetchShowAll, Category: fetchGroup, Profile: profileName, Logger: logger})
 		},
 	}
 	fetchCmd.Flags().BoolVar(&fetchShowAll, "all", false, "show all classes, ignoring configured interests")
+	fetchCmd.Flags().StringVar(&fetchGroup, "category", "", "only fetch classes in this category (see clubs groups)")
 
 	scheduleCmd := &cobra.Command{
 		Use:   "schedule",
@@ -45,14 +82,280 @@ func main() {
 			if err != nil {
 				return err
 			}
-			return worldclass.RunSchedule(cfg, worldclass.ScheduleOptions{Loop: scheduleLoop})
+			return worldclass.RunSchedule(cfg, worldclass.ScheduleOptions{
+				Loop:    scheduleLoop,
+				Profile: profileName,
+				Logger:  logger,
+				DryRun:  scheduleDry,
+				Weeks:   dryRunWeeks,
+			})
 		},
 	}
 	scheduleCmd.Flags().BoolVar(&scheduleLoop, "loop", false, "continuously monitor and book upcoming classes")
+	scheduleCmd.Flags().BoolVar(&scheduleDry, "dry-run", false, "report intended actions without booking; with --loop, print the wake timeline")
+	scheduleCmd.Flags().IntVar(&dryRunWeeks, "weeks", 2, "number of weeks covered by the --loop --dry-run timeline")
+
+	nextCmd := &cobra.Command{
+		Use:     "next",
+		Aliases: []string{"timeline"},
+		Short:   "Show upcoming class starts and booking window openings",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := worldclass.LoadConfig(cfgPath)
+			if err != nil {
+				return err
+			}
+			return worldclass.RunNext(cfg, worldclass.NextOptions{Profile: profileName, Count: nextCount, Out: cmd.OutOrStdout()})
+		},
+	}
+	nextCmd.Flags().IntVarP(&nextCount, "count", "n", 10, "number of upcoming classes to list")
+
+	bookingsCmd := &cobra.Command{
+		Use:   "bookings",
+		Short: "List the classes you are currently booked into",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := worldclass.LoadConfig(cfgPath)
+			if err != nil {
+				return err
+			}
+			return worldclass.RunBookings(cfg, worldclass.BookingsOptions{Profile: profileName, Format: outputFormat, Out: cmd.OutOrStdout()})
+		},
+	}
+	bookingsCmd.Flags().StringVarP(&outputFormat, "output", "o", worldclass.FormatTable, "output format: table, json or csv")
+
+	clubsCmd := &cobra.Command{
+		Use:   "clubs",
+		Short: "Discover World Class clubs",
+	}
+	clubsListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List every club ID and name from the member site",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := worldclass.LoadConfigForDiscovery(cfgPath)
+			if err != nil {
+				return err
+			}
+			return worldclass.RunClubsList(cfg, worldclass.ClubsOptions{
+				Profile:    profileName,
+				Match:      clubMatch,
+				Write:      clubsWrite,
+				ConfigPath: cfgPath,
+				Out:        cmd.OutOrStdout(),
+				Logger:     logger,
+			})
+		},
+	}
+	clubsListCmd.Flags().StringVar(&clubMatch, "match", "", "only list clubs whose name contains this text")
+	clubsListCmd.Flags().BoolVar(&clubsWrite, "write", false, "add the listed clubs missing from the profile's clubs to the config file")
+	clubsGroupsCmd := &cobra.Command{
+		Use:   "groups",
+		Short: "List the class categories that clubs and interests can be restricted to",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := worldclass.LoadConfigForDiscovery(cfgPath)
+			if err != nil {
+				return err
+			}
+			return worldclass.RunClubsGroups(cfg, worldclass.ClubsOptions{Profile: profileName, Out: cmd.OutOrStdout(), Logger: logger})
+		},
+	}
+	clubsCmd.AddCommand(clubsListCmd, clubsGroupsCmd)
 
-	rootCmd.AddCommand(fetchCmd, scheduleCmd)
+	interestsCmd := &cobra.Command{
+		Use:   "interests",
+		Short: "List, add or remove configured interests",
+	}
+	runInterests := func(run func(*worldclass.Config, worldclass.InterestsOptions) error) func(*cobra.Command, []string) error {
+		return func(cmd *cobra.Command, args []string) error {
+			cfg, err := worldclass.LoadConfig(cfgPath)
+			if err != nil {
+				return err
+			}
+			opts := interestOpts
+			opts.Profile = profileName
+			opts.ConfigPath = cfgPath
+			opts.In = cmd.InOrStdin()
+			opts.Out = cmd.OutOrStdout()
+			opts.Logger = logger
+			return run(cfg, opts)
+		}
+	}
+	interestsListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List configured interests with their numbers",
+		RunE:  runInterests(worldclass.RunInterestsList),
+	}
+	interestsAddCmd := &cobra.Command{
+		Use:   "add",
+		Short: "Add an interest picked from the scraped schedule",
+		RunE:  runInterests(worldclass.RunInterestsAdd),
+	}
+	interestsAddCmd.Flags().StringVar(&interestOpts.ClassID, "class-id", "", "class ID to add (as shown by fetch --all); prompts when omitted")
+	interestsAddCmd.Flags().StringVar(&interestOpts.Club, "club", "", "only offer classes from this club")
+	interestsRemoveCmd := &cobra.Command{
+		Use:   "remove",
+		Short: "Remove an interest from the configuration",
+		RunE:  runInterests(worldclass.RunInterestsRemove),
+	}
+	interestsRemoveCmd.Flags().StringVar(&interestOpts.Club, "club", "", "club of the interest to remove")
+	interestsRemoveCmd.Flags().IntVar(&interestOpts.Index, "index", 0, "number of the interest within the club (see interests list); prompts when omitted")
+	interestsCmd.AddCommand(interestsListCmd, interestsAddCmd, interestsRemoveCmd)
 
-	if err := rootCmd.Execute(); err != nil {
+	tuiCmd := &cobra.Command{
+		Use:   "tui",
+		Short: "Browse club schedules in a terminal UI and book, cancel or track classes",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := worldclass.LoadConfig(cfgPath)
+			if err != nil {
+				return err
+			}
+			// Log lines on stdout would corrupt the screen, so only a log file receives them.
+			var tuiLogger *slog.Logger
+			if logOpts.File != "" {
+				tuiLogger = logger
+			}
+			return worldclass.RunTUI(cfg, worldclass.TUIOptions{Profile: profileName, ConfigPath: cfgPath, Logger: tuiLogger})
+		},
+	}
+
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve a password-protected web dashboard for schedules and bookings",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := worldclass.LoadConfig(cfgPath)
+			if err != nil {
+				return err
+			}
+			opts := serveOpts
+			opts.Profile = profileName
+			opts.Logger = logger
+			return worldclass.RunServe(cfg, opts)
+		},
+	}
+	serveCmd.Flags().StringVar(&serveOpts.Address, "addr", "", "listen address (default dashboard.address or 127.0.0.1:8080)")
+	serveCmd.Flags().StringVar(&serveOpts.Password, "password", os.Getenv("WORLDCLASS_DASHBOARD_PASSWORD"), "dashboard password (default dashboard.password)")
+	serveCmd.Flags().BoolVar(&serveOpts.Loop, "loop", false, "also run the booking loop and show its status")
+
+	statsCmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Report how fast classes fill and how often interests get booked",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := worldclass.LoadConfig(cfgPath)
+			if err != nil {
+				return err
+			}
+			return worldclass.RunStats(cfg, worldclass.StatsOptions{Profile: profileName, Out: cmd.OutOrStdout()})
+		},
+	}
+
+	reportCmd := &cobra.Command{
+		Use:   "report",
+		Short: "Summarise attended classes, cancellations, missed windows and streaks per week or month",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := worldclass.LoadConfig(cfgPath)
+			if err != nil {
+				return err
+			}
+			opts := reportOpts
+			opts.Profile = profileName
+			opts.Logger = logger
+			opts.Out = cmd.OutOrStdout()
+			if reportFile != "" {
+				file, err := os.Create(reportFile)
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+				opts.Out = file
+			}
+			return worldclass.RunReport(cfg, opts)
+		},
+	}
+	reportCmd.Flags().StringVar(&reportOpts.Period, "period", worldclass.PeriodWeek, "summary period: week or month")
+	reportCmd.Flags().IntVarP(&reportOpts.Periods, "count", "n", 4, "number of periods to include, counting back from the current one")
+	reportCmd.Flags().StringVarP(&reportOpts.Format, "format", "f", worldclass.FormatMarkdown, "report format: markdown or html")
+	reportCmd.Flags().StringVarP(&reportFile, "output", "o", "", "write the report to this file instead of stdout")
+	reportCmd.Flags().BoolVar(&reportOpts.Sync, "sync", false, "first record the booked classes scraped from the site, so website bookings are counted")
+
+	diffCmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Show how the schedule changed since the last snapshot and which interests it affects",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := worldclass.LoadConfig(cfgPath)
+			if err != nil {
+				return err
+			}
+			return worldclass.RunDiff(cfg, worldclass.DiffOptions{Profile: profileName, Offline: diffOffline, Out: cmd.OutOrStdout()})
+		},
+	}
+	diffCmd.Flags().BoolVar(&diffOffline, "offline", false, "compare the two latest snapshots instead of fetching the schedule")
+
+	healthCmd := &cobra.Command{
+		Use:   "health",
+		Short: "Check that every interest matches a class this week and suggest the nearest ones otherwise",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := worldclass.LoadConfig(cfgPath)
+			if err != nil {
+				return err
+			}
+			return worldclass.RunHealth(cfg, worldclass.HealthOptions{Profile: profileName, Out: cmd.OutOrStdout()})
+		},
+	}
+
+	pauseCmd := &cobra.Command{
+		Use:   "pause",
+		Short: "Stop the booking loop from booking until a date, or show the current pauses",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := worldclass.LoadConfig(cfgPath)
+			if err != nil {
+				return err
+			}
+			if pauseUntil == "" {
+				if pauseFrom != "" || pauseOpts.CancelBooked {
+					return fmt.Errorf("--until is required to pause")
+				}
+				return worldclass.RunPauseStatus(cfg, cmd.OutOrStdout())
+			}
+
+			opts := pauseOpts
+			opts.Profile = profileName
+			opts.Out = cmd.OutOrStdout()
+			if opts.Until, err = worldclass.ParsePauseTime(cfg, pauseUntil, true); err != nil {
+				return fmt.Errorf("--until: %w", err)
+			}
+			if pauseFrom != "" {
+				if opts.From, err = worldclass.ParsePauseTime(cfg, pauseFrom, false); err != nil {
+					return fmt.Errorf("--from: %w", err)
+				}
+			}
+			return worldclass.RunPause(cfg, opts)
+		},
+	}
+	pauseCmd.Flags().StringVar(&pauseUntil, "until", "", "end of the pause: YYYY-MM-DD (inclusive), \"YYYY-MM-DD HH:MM\" or RFC 3339")
+	pauseCmd.Flags().StringVar(&pauseFrom, "from", "", "start of the pause in the same formats (default now)")
+	pauseCmd.Flags().StringVar(&pauseOpts.Reason, "reason", "", "note shown with the pause")
+	pauseCmd.Flags().BoolVar(&pauseOpts.CancelBooked, "cancel-booked", false, "cancel booked classes inside the pause (always on with pause.cancel_booked)")
+
+	resumeCmd := &cobra.Command{
+		Use:   "resume",
+		Short: "Lift the pause of the selected profile, or every pause",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := worldclass.LoadConfig(cfgPath)
+			if err != nil {
+				return err
+			}
+			return worldclass.RunResume(cfg, worldclass.ResumeOptions{Profile: profileName, Out: cmd.OutOrStdout()})
+		},
+	}
+
+	rootCmd.AddCommand(fetchCmd, scheduleCmd, nextCmd, bookingsCmd, clubsCmd, interestsCmd, tuiCmd, serveCmd, statsCmd, reportCmd, diffCmd, healthCmd, pauseCmd, resumeCmd)
+
+	// The log file is closed here rather than in PersistentPostRunE, which cobra skips when a command fails.
+	err := rootCmd.Execute()
+	if logCloser != nil {
+		if closeErr := logCloser.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,159 @@
+package worldclass
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// Output formats accepted by RunBookings.
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatCSV   = "csv"
+)
+
+// BookingsOptions controls the behavior of RunBookings.
+type BookingsOptions struct {
+	Profile string
+	Format  string
+	Out     io.Writer
+}
+
+type bookingRecord struct {
+	Profile string    `json:"profile"`
+	Club    string    `json:"club"`
+	Start   time.Time `json:"start"`
+	Day     string    `json:"day"`
+	Time    string    `json:"time"`
+	Title   string    `json:"title"`
+	Trainer string    `json:"trainer,omitempty"`
+	Room    string    `json:"room,omitempty"`
+	ClassID string    `json:"class_id"`
+}
+
+// RunBookings lists the classes the selected profiles are booked into, ordered by start time.
+func RunBookings(cfg *Config, opts BookingsOptions) error {
+	if cfg == nil {
+		return fmt.Errorf("configuration is required")
+	}
+
+	format := opts.Format
+	if format == "" {
+		format = FormatTable
+	}
+	if format != FormatTable && format != FormatJSON && format != FormatCSV {
+		return fmt.Errorf("unsupported output format %q (expected table, json or csv)", format)
+	}
+
+	profiles, err := cfg.SelectProfiles(opts.Profile)
+	if err != nil {
+		return err
+	}
+
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return fmt.Errorf("load timezone %s: %w", cfg.Timezone, err)
+	}
+
+	out := opts.Out
+	if out == nil {
+		out = os.Stdout
+	}
+
+	now := time.Now().In(location)
+	var records []bookingRecord
+	for _, profile := range profiles {
+		acct, err := newAccount(cfg, profile, nil, nil)
+		if err != nil {
+			return err
+		}
+
+		// Listing bookings only reads the schedule; snapshots are left to the runs that book.
+		ctx, cancel := context.WithTimeout(withoutSnapshot(context.Background()), 30*time.Second)
+		classes, err := acct.fetcher.FetchClasses(ctx, profile.Credentials, profile.Clubs)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("profile %s: %w", profile.Name, err)
+		}
+
+		for _, classInfo := range classes {
+			if !classInfo.Booked {
+				continue
+			}
+			start, _ := classDate(classInfo, location, now)
+			records = append(records, bookingRecord{
+				Profile: profile.Name,
+				Club:    classInfo.ClubName,
+				Start:   start,
+				Day:     classInfo.Day,
+				Time:    classInfo.Time,
+				Title:   classInfo.Title,
+				Trainer: classInfo.Trainer,
+				Room:    classInfo.Room,
+				ClassID: classInfo.ClassID,
+			})
+		}
+	}
+
+	// Classes whose start could not be parsed sort last, in scrape order.
+	sort.SliceStable(records, func(i, j int) bool {
+		a, b := records[i].Start, records[j].Start
+		if a.IsZero() || b.IsZero() {
+			return !a.IsZero() && b.IsZero()
+		}
+		return a.Before(b)
+	})
+
+	switch format {
+	case FormatJSON:
+		if records == nil {
+			records = []bookingRecord{}
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	case FormatCSV:
+		return writeBookingsCSV(out, records)
+	default:
+		return writeBookingsTable(out, records)
+	}
+}
+
+func writeBookingsTable(out io.Writer, records []bookingRecord) error {
+	if len(records) == 0 {
+		_, err := fmt.Fprintln(out, "no booked classes")
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROFILE\tCLUB\tDAY\tTIME\tCLASS\tTRAINER\tROOM")
+	for _, r := range records {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.Profile, r.Club, r.Day, r.Time, r.Title, r.Trainer, r.Room)
+	}
+	return w.Flush()
+}
+
+func writeBookingsCSV(out io.Writer, records []bookingRecord) error {
+	w := csv.NewWriter(out)
+	if err := w.Write([]string{"profile", "club", "start", "day", "time", "title", "trainer", "room", "class_id"}); err != nil {
+		return err
+	}
+	for _, r := range records {
+		start := ""
+		if !r.Start.IsZero() {
+			start = r.Start.Format(time.RFC3339)
+		}
+		if err := w.Write([]string{r.Profile, r.Club, start, r.Day, r.Time, r.Title, r.Trainer, r.Room, r.ClassID}); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,165 @@
+package worldclass
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// loadSkipDates parses the skip dates and imports the events of the .ics calendars; relative calendar paths
+// are resolved from dir and event times are converted to location.
+func loadSkipDates(dir string, location *time.Location, dates, calendars []string) ([]dateRange, error) {
+	ranges, err := parseDateRanges(dates)
+	if err != nil {
+		return nil, fmt.Errorf("skip: %w", err)
+	}
+
+	for _, path := range calendars {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("skip calendar: %w", err)
+		}
+		events, err := parseICS(file, location)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("skip calendar %s: %w", path, err)
+		}
+		ranges = append(ranges, events...)
+	}
+	return ranges, nil
+}
+
+// icsValue is a DTSTART or DTEND property: its value and the TZID parameter, if any.
+type icsValue struct {
+	value string
+	tzid  string
+}
+
+// isDate reports whether the value is a DATE, which is exactly eight digits; DATE-TIME values carry a time.
+func (v icsValue) isDate() bool {
+	return len(strings.TrimSpace(v.value)) == len("20060102")
+}
+
+// parseICS returns the days covered by each VEVENT of an iCalendar file, in location. All-day events end the
+// day before their exclusive DTEND; timed events cover every day they touch once converted to location.
+// Recurrence rules are not expanded.
+func parseICS(r io.Reader, location *time.Location) ([]dateRange, error) {
+	var (
+		ranges     []dateRange
+		inEvent    bool
+		start, end icsValue
+		lines      []string
+	)
+
+	// Long lines are folded onto continuation lines starting with a space or tab.
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, line := range lines {
+		property, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		params := strings.Split(property, ";")
+		name := strings.ToUpper(params[0])
+		prop := icsValue{value: value}
+		for _, param := range params[1:] {
+			if key, paramValue, ok := strings.Cut(param, "="); ok && strings.EqualFold(key, "TZID") {
+				prop.tzid = strings.Trim(paramValue, `"`)
+			}
+		}
+
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			inEvent, start, end = true, icsValue{}, icsValue{}
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			inEvent = false
+			if start.value == "" {
+				continue
+			}
+			event, err := icsEventRange(start, end, location)
+			if err != nil {
+				return nil, err
+			}
+			ranges = append(ranges, event)
+		case inEvent && name == "DTSTART":
+			start = prop
+		case inEvent && name == "DTEND":
+			end = prop
+		}
+	}
+	return ranges, nil
+}
+
+func icsEventRange(start, end icsValue, location *time.Location) (dateRange, error) {
+	from, err := icsTime(start, location)
+	if err != nil {
+		return dateRange{}, fmt.Errorf("DTSTART: %w", err)
+	}
+	if end.value == "" {
+		return dateRange{from: civilDateOf(from), until: civilDateOf(from)}, nil
+	}
+
+	until, err := icsTime(end, location)
+	if err != nil {
+		return dateRange{}, fmt.Errorf("DTEND: %w", err)
+	}
+	if end.isDate() {
+		until = until.AddDate(0, 0, -1)
+	}
+	if until.Before(from) {
+		until = from
+	}
+	return dateRange{from: civilDateOf(from), until: civilDateOf(until)}, nil
+}
+
+// icsTime reads an iCalendar DATE or DATE-TIME value in location. DATE values (20261220) and floating times
+// (20261220T090000) are taken as they are written, UTC times (20261220T230000Z) are converted, and times with
+// a TZID are read in that zone, falling back to location for zone names the system does not know.
+func icsTime(v icsValue, location *time.Location) (time.Time, error) {
+	value := strings.TrimSpace(v.value)
+	if v.isDate() {
+		date, err := time.ParseInLocation("20060102", value, location)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date %q", value)
+		}
+		return date, nil
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date-time %q", value)
+		}
+		return t.In(location), nil
+	}
+
+	zone := location
+	if v.tzid != "" {
+		if named, err := time.LoadLocation(v.tzid); err == nil {
+			zone = named
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, zone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date-time %q", value)
+	}
+	return t.In(location), nil
+}
@@ -0,0 +1,77 @@
+package worldclass
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseICS(t *testing.T) {
+	bucharest, err := time.LoadLocation("Europe/Bucharest")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		event string
+		want  []dateRange
+	}{
+		{
+			name:  "all-day event ends before DTEND",
+			event: "DTSTART;VALUE=DATE:20261220\r\nDTEND;VALUE=DATE:20261223\r\n",
+			want:  []dateRange{{from: 20261220, until: 20261222}},
+		},
+		{
+			name:  "all-day event without DTEND",
+			event: "DTSTART;VALUE=DATE:20261220\r\n",
+			want:  []dateRange{{from: 20261220, until: 20261220}},
+		},
+		{
+			name:  "UTC time late in the evening falls on the next local day",
+			event: "DTSTART:20261219T230000Z\r\nDTEND:20261220T010000Z\r\n",
+			want:  []dateRange{{from: 20261220, until: 20261220}},
+		},
+		{
+			name:  "TZID time is converted",
+			event: "DTSTART;TZID=America/New_York:20261219T190000\r\nDTEND;TZID=America/New_York:20261219T200000\r\n",
+			want:  []dateRange{{from: 20261220, until: 20261220}},
+		},
+		{
+			name:  "unknown TZID falls back to the configured zone",
+			event: "DTSTART;TZID=\"GTB Standard Time\":20261219T190000\r\nDTEND;TZID=\"GTB Standard Time\":20261219T200000\r\n",
+			want:  []dateRange{{from: 20261219, until: 20261219}},
+		},
+		{
+			name:  "floating time spanning midnight",
+			event: "DTSTART:20261219T220000\r\nDTEND:20261220T020000\r\n",
+			want:  []dateRange{{from: 20261219, until: 20261220}},
+		},
+		{
+			name:  "folded line",
+			event: "DTSTART;TZID=Europe/Bu\r\n charest:20261219T100000\r\n",
+			want:  []dateRange{{from: 20261219, until: 20261219}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calendar := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nSUMMARY:Holiday\r\n" + tt.event + "END:VEVENT\r\nEND:VCALENDAR\r\n"
+			got, err := parseICS(strings.NewReader(calendar), bucharest)
+			if err != nil {
+				t.Fatalf("parseICS: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("parseICS() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseICSRejectsInvalidDates(t *testing.T) {
+	calendar := "BEGIN:VEVENT\r\nDTSTART:2026122\r\nEND:VEVENT\r\n"
+	if _, err := parseICS(strings.NewReader(calendar), time.UTC); err == nil {
+		t.Error("parseICS accepted a malformed DTSTART")
+	}
+}
@@ -0,0 +1,78 @@
+package worldclass
+
+import (
+	"slices"
+	"strings"
+
+	wc "github.com/tatulea/worldclass-scheduler/pkg/worldclass"
+)
+
+// clubsForInterests narrows each club's schedule request to the categories its interests ask for.
+// Clubs with explicit groups are left alone; a club that also has interests without a category, or any
+// club when keepAll is set, keeps the all-categories request so those still see every class. That request
+// comes first, so the merged classes keep the schedule's order.
+func clubsForInterests(clubs []Club, interests map[string][]ClassInterest, keepAll bool) []Club {
+	categories := make(map[string][]string)
+	needsAll := make(map[string]bool)
+	for _, clubName := range sortedKeys(interests) {
+		for _, interest := range interests[clubName] {
+			for _, link := range fallbackChain(clubName, interest) {
+				category := strings.TrimSpace(link.interest.Category)
+				if category == "" {
+					needsAll[link.club] = true
+					continue
+				}
+				if !slices.ContainsFunc(categories[link.club], func(existing string) bool {
+					return strings.EqualFold(existing, category)
+				}) {
+					categories[link.club] = append(categories[link.club], category)
+				}
+			}
+		}
+	}
+
+	narrowed := make([]Club, 0, len(clubs))
+	for _, club := range clubs {
+		if len(club.Groups) == 0 && len(categories[club.Name]) > 0 {
+			groups := make([]Group, 0, len(categories[club.Name])+1)
+			if keepAll || needsAll[club.Name] {
+				groups = append(groups, Group{ID: wc.AllGroupsID})
+			}
+			for _, category := range categories[club.Name] {
+				groups = append(groups, Group{Name: category})
+			}
+			club.Groups = groups
+		}
+		narrowed = append(narrowed, club)
+	}
+	return narrowed
+}
+
+// scheduleClubs returns the clubs to fetch when matching interests. Booking rules count every booked class,
+// so with rules configured the all-categories request is kept next to the interest categories.
+func (a *account) scheduleClubs() []Club {
+	hasRules := a.rules != (BookingRules{}) || len(a.clubRules) > 0
+	return clubsForInterests(a.profile.Clubs, a.profile.Interests, hasRules)
+}
+
+// displayClubs returns the clubs to fetch when showing the whole schedule: every category, plus the
+// categories interests ask for so their classes carry Class.Category and category interests match.
+func (a *account) displayClubs() []Club {
+	return clubsForInterests(a.profile.Clubs, a.profile.Interests, true)
+}
+
+// clubsForCategory restricts every club to a single category, replacing any configured groups.
+func clubsForCategory(clubs []Club, category string) []Club {
+	narrowed := make([]Club, 0, len(clubs))
+	for _, club := range clubs {
+		club.Groups = []Group{{Name: category}}
+		narrowed = append(narrowed, club)
+	}
+	return narrowed
+}
+
+// categoryMatches reports whether a class belongs to category; an empty category matches every class.
+func categoryMatches(classInfo Class, category string) bool {
+	category = strings.TrimSpace(category)
+	return category == "" || strings.EqualFold(strings.TrimSpace(classInfo.Category), category)
+}
@@ -0,0 +1,30 @@
+package worldclass
+
+import (
+	"reflect"
+	"testing"
+
+	wc "github.com/tatulea/worldclass-scheduler/pkg/worldclass"
+)
+
+func TestDisplayClubs(t *testing.T) {
+	acct := newTestAccount(&fakeFetcher{}, &fakeBooker{})
+	acct.profile.Clubs = []Club{
+		{ID: "454", Name: "Park Lake"},
+		{ID: "458", Name: "Titan Park"},
+		{ID: "460", Name: "Grand Arena", Groups: []Group{{Name: "Aqua"}}},
+	}
+	acct.profile.Interests = map[string][]ClassInterest{
+		"Park Lake":   {{Title: "PILATES", Category: "Mind & Body"}},
+		"Grand Arena": {{Title: "AQUA GYM", Category: "Aqua"}},
+	}
+
+	want := []Club{
+		{ID: "454", Name: "Park Lake", Groups: []Group{{ID: wc.AllGroupsID}, {Name: "Mind & Body"}}},
+		{ID: "458", Name: "Titan Park"},
+		{ID: "460", Name: "Grand Arena", Groups: []Group{{Name: "Aqua"}}},
+	}
+	if got := acct.displayClubs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("displayClubs() = %+v, want %+v", got, want)
+	}
+}
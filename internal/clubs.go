@@ -0,0 +1,171 @@
+package worldclass
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/goccy/go-yaml/ast"
+	wc "github.com/tatulea/worldclass-scheduler/pkg/worldclass"
+)
+
+// ClubsOptions controls the behavior of RunClubsList and RunClubsGroups.
+type ClubsOptions struct {
+	Profile string
+	// Match keeps only clubs whose name contains it, case-insensitively.
+	Match string
+	// Write adds the listed clubs that are missing from the selected profile's clubs to the config file.
+	Write      bool
+	ConfigPath string
+	Out        io.Writer
+	Logger     *slog.Logger
+}
+
+// RunClubsList prints every club offered on the member site and optionally stores them in the config file.
+func RunClubsList(cfg *Config, opts ClubsOptions) error {
+	if cfg == nil {
+		return fmt.Errorf("configuration is required")
+	}
+
+	// Every member sees the same club selector, so the first account is enough; writing needs the one
+	// profile whose clubs are extended.
+	var (
+		profileIdx int
+		profile    Profile
+		err        error
+	)
+	if opts.Write {
+		profileIdx, profile, err = editableProfile(cfg, opts.Profile)
+	} else {
+		var profiles []Profile
+		profiles, err = cfg.SelectProfiles(opts.Profile)
+		if err == nil {
+			profile = profiles[0]
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	client, err := wc.NewClient(cfg.BaseURL, loggerOrDefault(opts.Logger))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	clubs, err := client.ListClubs(ctx, profile.Credentials)
+	if err != nil {
+		return fmt.Errorf("profile %s: %w", profile.Name, err)
+	}
+
+	if opts.Match != "" {
+		needle := strings.ToLower(opts.Match)
+		matched := clubs[:0]
+		for _, club := range clubs {
+			if strings.Contains(strings.ToLower(club.Name), needle) {
+				matched = append(matched, club)
+			}
+		}
+		clubs = matched
+	}
+
+	out := opts.Out
+	if out == nil {
+		out = os.Stdout
+	}
+
+	if len(clubs) == 0 {
+		fmt.Fprintln(out, "no clubs matched")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME")
+	for _, club := range clubs {
+		fmt.Fprintf(w, "%s\t%s\n", club.ID, club.Name)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if !opts.Write {
+		return nil
+	}
+	if opts.ConfigPath == "" {
+		return fmt.Errorf("config path is required to write clubs")
+	}
+	merged, added := mergeClubs(profile.Clubs, clubs)
+	if added == 0 {
+		fmt.Fprintf(out, "Every listed club is already configured for profile %s.\n", profile.Name)
+		return nil
+	}
+	err = editConfigFile(opts.ConfigPath, func(file *ast.File) error {
+		return setNodeKey(file, profileConfigPath(file, profileIdx), "clubs", merged)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Added %d clubs to profile %s in %s.\n", added, profile.Name, opts.ConfigPath)
+	return nil
+}
+
+// mergeClubs appends the clubs of listed whose ID is not configured yet to configured, leaving the configured
+// entries (their names, which interests refer to, and their groups) as they are.
+func mergeClubs(configured, listed []Club) ([]Club, int) {
+	merged := slices.Clone(configured)
+	added := 0
+	for _, club := range listed {
+		if slices.ContainsFunc(merged, func(c Club) bool { return c.ID == club.ID }) {
+			continue
+		}
+		merged = append(merged, club)
+		added++
+	}
+	return merged, added
+}
+
+// RunClubsGroups prints the class categories offered by the schedule's group selector.
+func RunClubsGroups(cfg *Config, opts ClubsOptions) error {
+	if cfg == nil {
+		return fmt.Errorf("configuration is required")
+	}
+
+	profiles, err := cfg.SelectProfiles(opts.Profile)
+	if err != nil {
+		return err
+	}
+	profile := profiles[0]
+
+	client, err := wc.NewClient(cfg.BaseURL, loggerOrDefault(opts.Logger))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	groups, err := client.ListGroups(ctx, profile.Credentials)
+	if err != nil {
+		return fmt.Errorf("profile %s: %w", profile.Name, err)
+	}
+
+	out := opts.Out
+	if out == nil {
+		out = os.Stdout
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCATEGORY")
+	for _, group := range groups {
+		fmt.Fprintf(w, "%s\t%s\n", group.ID, group.Name)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,20 @@
+package worldclass
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeClubs(t *testing.T) {
+	configured := []Club{{ID: "454", Name: "Park Lake", Groups: []Group{{Name: "Cardio"}}}}
+	listed := []Club{{ID: "454", Name: "World Class Park Lake"}, {ID: "458", Name: "Titan Park"}}
+
+	merged, added := mergeClubs(configured, listed)
+	want := []Club{{ID: "454", Name: "Park Lake", Groups: []Group{{Name: "Cardio"}}}, {ID: "458", Name: "Titan Park"}}
+	if added != 1 || !reflect.DeepEqual(merged, want) {
+		t.Errorf("mergeClubs() = %+v, %d; want %+v, 1", merged, added, want)
+	}
+	if len(configured) != 1 {
+		t.Errorf("mergeClubs modified the configured clubs: %+v", configured)
+	}
+}
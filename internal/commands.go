@@ -4,12 +4,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/getsentry/sentry-go"
+	wc "github.com/tatulea/worldclass-scheduler/pkg/worldclass"
 )
 
 const (
@@ -23,11 +30,21 @@ const (
 // FetchOptions controls the behavior of RunFetch.
 type FetchOptions struct {
 	ShowAll bool
+	// Category fetches and lists only classes in this schedule group.
+	Category string
+	Profile  string
+	Logger   *slog.Logger
 }
 
 // ScheduleOptions controls the behavior of RunSchedule.
 type ScheduleOptions struct {
-	Loop bool
+	Loop    bool
+	Profile string
+	Logger  *slog.Logger
+	// DryRun reports the intended action per interest without booking; with Loop it prints the wake timeline.
+	DryRun bool
+	// Weeks is how far ahead the dry-run loop timeline reaches.
+	Weeks int
 }
 
 // RunFetch executes the fetch workflow, optionally filtering classes against the configured interests.
@@ -36,59 +53,65 @@ func RunFetch(cfg *Config, opts FetchOptions) error {
 		return fmt.Errorf("configuration is required")
 	}
 
-	client, err := NewWorldClassClient(cfg.BaseURL, logf)
+	profiles, err := cfg.SelectProfiles(opts.Profile)
 	if err != nil {
 		return err
 	}
 
+	for _, profile := range profiles {
+		acct, err := newAccount(cfg, profile, nil, opts.Logger)
+		if err != nil {
+			return err
+		}
+		if err := fetchAccount(acct, opts); err != nil {
+			return fmt.Errorf("profile %s: %w", profile.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func fetchAccount(acct *account, opts FetchOptions) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	classes, err := client.FetchClasses(ctx, cfg.Credentials, cfg.Clubs)
+	var clubs []Club
+	switch {
+	case opts.Category != "":
+		clubs = clubsForCategory(acct.profile.Clubs, opts.Category)
+	case opts.ShowAll:
+		clubs = acct.profile.Clubs
+	default:
+		clubs = acct.scheduleClubs()
+	}
+
+	classes, err := acct.fetcher.FetchClasses(ctx, acct.profile.Credentials, clubs)
 	if err != nil {
 		return err
 	}
 
 	if !opts.ShowAll {
-		classes = filterClassesForInterests(classes, cfg.Interests, logf)
+		classes = filterClassesForInterests(classes, acct.profile.Interests, acct.clock.Now().In(acct.location), acct.log)
+	}
+	if opts.Category != "" {
+		classes = slices.DeleteFunc(classes, func(classInfo Class) bool {
+			return !categoryMatches(classInfo, opts.Category)
+		})
 	}
 
 	if len(classes) == 0 {
-		logf("no classes matched your filters")
+		acct.log.Info("no classes matched your filters")
 		return nil
 	}
 
 	for _, classInfo := range classes {
 		switch {
 		case classInfo.Booked:
-			logf(
-				"Already booked: %s | %s | %s | %s | Trainer: %s | ClassID: %s",
-				classInfo.ClubName,
-				classInfo.Day,
-				classInfo.Time,
-				classInfo.Title,
-				classInfo.Trainer,
-				classInfo.ClassID,
-			)
+			acct.log.Info("already booked", classAttrs(classInfo)...)
 		case classInfo.Bookable:
-			logf(
-				"Bookable: %s | %s | %s | %s | Trainer: %s | ClassID: %s",
-				classInfo.ClubName,
-				classInfo.Day,
-				classInfo.Time,
-				classInfo.Title,
-				classInfo.Trainer,
-				classInfo.ClassID,
-			)
+			acct.log.Info("bookable", classAttrs(classInfo)...)
 		default:
-			logf(
-				"Scheduled (booking closed): %s | %s | %s | Trainer: %s | Title: %s",
-				classInfo.ClubName,
-				classInfo.Day,
-				classInfo.Time,
-				classInfo.Trainer,
-				classInfo.Title,
-			)
+			acct.log.Info("scheduled (booking closed)", classAttrs(classInfo)...)
 		}
 	}
 
@@ -101,131 +124,191 @@ func RunSchedule(cfg *Config, opts ScheduleOptions) error {
 		return fmt.Errorf("configuration is required")
 	}
 
-	if opts.Loop {
-		return runScheduleLoop(cfg)
-	}
-
-	return runScheduleOnce(cfg)
-}
-
-func runScheduleOnce(cfg *Config) error {
-	client, err := NewWorldClassClient(cfg.BaseURL, logf)
+	profiles, err := cfg.SelectProfiles(opts.Profile)
 	if err != nil {
 		return err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	switch {
+	case opts.DryRun && opts.Loop:
+		return runScheduleTimeline(cfg, profiles, opts.Logger, opts.Weeks)
+	case opts.DryRun:
+		return runScheduleDryRun(cfg, profiles, opts.Logger)
+	case opts.Loop:
+		return runScheduleLoop(cfg, profiles, opts.Logger)
+	}
 
-	_, err = scheduleInterests(ctx, client, cfg, cfg.Interests, false)
-	return err
+	return runScheduleOnce(cfg, profiles, opts.Logger)
 }
 
-func runScheduleLoop(cfg *Config) error {
+// account bundles the per-profile client, logger and Sentry hub so that profiles stay isolated.
+type account struct {
+	profile   Profile
+	rules     BookingRules
+	clubRules map[string]BookingRules
+	fetcher   Fetcher
+	booker    Booker
+	clock     Clock
+	log       *slog.Logger
+	hub       *sentry.Hub
+	notifier  Notifier
+	// dryRun stops short of logging in and booking; attempts report statusWouldBook instead.
+	dryRun bool
+	// disableCheckIns skips the per-window Sentry Cron check-ins.
+	disableCheckIns bool
+	// peers holds every configured profile by name so group interests can book companions.
+	peers map[string]Profile
+	// state is updated by the Scheduler for the dashboard; nil when nobody watches the loop.
+	state *loopState
+	// store persists booking windows, fill samples, the booking history and schedule snapshots under the
+	// config's state_dir.
+	store    *stateStore
+	location *time.Location
+	health   HealthConfig
+	pause    PauseConfig
+	// notifications tracks deliveries still running in the background; failuresNotified holds the classes
+	// whose booking_failed event was sent in the current booking window.
+	notifications    sync.WaitGroup
+	failuresMu       sync.Mutex
+	failuresNotified map[string]bool
+	// watchSchedule makes fetches that change the schedule snapshot log the changes and notify affected interests.
+	watchSchedule bool
+}
+
+func newAccount(cfg *Config, profile Profile, hub *sentry.Hub, logger *slog.Logger) (*account, error) {
+	logger = loggerOrDefault(logger).With("profile", profile.Name)
+
 	location, err := time.LoadLocation(cfg.Timezone)
 	if err != nil {
-		return fmt.Errorf("load timezone %s: %w", cfg.Timezone, err)
+		return nil, fmt.Errorf("load timezone %s: %w", cfg.Timezone, err)
 	}
 
-	client, err := NewWorldClassClient(cfg.BaseURL, logf)
+	client, err := wc.NewClient(cfg.BaseURL, logger)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	sentryEnabled, err := initSentry(cfg.Sentry.DSN)
+	notifier, err := NewNotifier(cfg.Notifications)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("notifications: %w", err)
 	}
 
-	if sentryEnabled {
-		defer sentry.Flush(5 * time.Second)
-		defer func() {
-			if r := recover(); r != nil {
-				sentry.CurrentHub().Recover(r)
-				sentry.Flush(5 * time.Second)
-				panic(r)
-			}
-		}()
+	if hub != nil {
+		hub = hub.Clone()
+		hub.ConfigureScope(func(scope *sentry.Scope) {
+			scope.SetTag("profile", profile.Name)
+		})
 	}
 
-	for {
-		now := time.Now().In(location)
-		handle, startTime, err := nextInterestOccurrence(cfg, location, now)
+	peers := make(map[string]Profile, len(cfg.Profiles))
+	for _, peer := range cfg.Profiles {
+		peers[peer.Name] = peer
+	}
+
+	acct := &account{
+		profile:   profile,
+		rules:     cfg.Rules,
+		clubRules: cfg.ClubRules,
+		fetcher:   client,
+		booker:    clientBooker{client: client},
+		clock:     realClock{},
+		log:       logger,
+		hub:       hub,
+		notifier:  notifier,
+		peers:     peers,
+		store:     newStateStore(cfg.StateDir),
+		location:  location,
+		health:    cfg.Health,
+		pause:     cfg.Pause,
+
+		disableCheckIns: cfg.Sentry.DisableCheckIns,
+	}
+	acct.fetcher = snapshotFetcher{next: client, acct: acct}
+	return acct, nil
+}
+
+func runScheduleOnce(cfg *Config, profiles []Profile, logger *slog.Logger) error {
+	var errs []error
+	for _, profile := range profiles {
+		acct, err := newAccount(cfg, profile, nil, logger)
 		if err != nil {
-			if errors.Is(err, errNoInterests) {
-				logf("no interests configured; sleeping for %s", idleLoopDelay)
-				time.Sleep(idleLoopDelay)
-				continue
-			}
-			reportLoopError(sentryEnabled, err, map[string]string{"phase": "next_interest"})
 			return err
 		}
 
-		wakeTime := startTime.Add(-bookingLeadTime).Add(-bookingEarlyBuffer)
-		if wakeTime.After(time.Now()) {
-			logf("Next class %s | %s | %s scheduled for %s, waking at %s", handle.Club, handle.Interest.Day, handle.Interest.Time, startTime.Format(time.RFC1123), wakeTime.Format(time.RFC1123))
-			time.Sleep(time.Until(wakeTime))
-		} else {
-			if time.Since(wakeTime) < bookingEarlyBuffer {
-				logf("Reached booking buffer for %s | %s | %s, polling until booking opens", handle.Club, handle.Interest.Day, handle.Interest.Time)
-			} else {
-				logf("Booking window already open for %s | %s | %s, attempting immediately", handle.Club, handle.Interest.Day, handle.Interest.Time)
-			}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		_, err = scheduleInterests(ctx, acct, profile.Interests, time.Time{})
+		cancel()
+		acct.flushNotifications()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("profile %s: %w", profile.Name, err))
 		}
+	}
 
-		deadline := startTime.Add(bookingGracePeriod)
-		bookedCurrent := false
-		for {
-			if time.Now().After(deadline) {
-				logf("Unable to book %s | %s | %s before cutoff; will retry next occurrence", handle.Club, handle.Interest.Day, handle.Interest.Time)
-				break
-			}
+	return errors.Join(errs...)
+}
 
-			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-			results, err := scheduleInterests(ctx, client, cfg, map[string][]ClassInterest{handle.Club: {handle.Interest}}, sentryEnabled)
-			cancel()
-			if err != nil {
-				logf("Scheduling attempt failed: %v", err)
-				reportLoopError(sentryEnabled, err, map[string]string{
-					"phase": "booking",
-					"club":  handle.Club,
-					"title": handle.Interest.Title,
-				})
-			} else if interestSatisfied(handle, results) {
-				bookedCurrent = true
-				break
-			}
+func runScheduleLoop(cfg *Config, profiles []Profile, logger *slog.Logger) error {
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return fmt.Errorf("load timezone %s: %w", cfg.Timezone, err)
+	}
+
+	hub, err := initSentry(cfg.Sentry)
+	if err != nil {
+		return err
+	}
+	if hub != nil {
+		defer sentry.Flush(5 * time.Second)
+	}
 
-			time.Sleep(bookingRetryDelay)
+	accounts := make([]*account, 0, len(profiles))
+	for _, profile := range profiles {
+		acct, err := newAccount(cfg, profile, hub, logger)
+		if err != nil {
+			return err
 		}
+		accounts = append(accounts, acct)
+	}
 
-		if bookedCurrent {
-			reference := startTime.Add(time.Second)
-			nextHandle, nextStart, err := nextInterestOccurrence(cfg, location, reference)
-			if err != nil {
-				if errors.Is(err, errNoInterests) {
-					logf("no interests configured; sleeping for %s", idleLoopDelay)
-					time.Sleep(idleLoopDelay)
-				} else {
-					reportLoopError(sentryEnabled, err, map[string]string{"phase": "next_interest_after_success"})
-					return err
-				}
-				continue
-			}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-			nextWake := nextStart.Add(-bookingLeadTime).Add(-bookingEarlyBuffer)
-			if sleepDuration := time.Until(nextWake); sleepDuration > 0 {
-				logf("Next class %s | %s | %s scheduled for %s, waking at %s", nextHandle.Club, nextHandle.Interest.Day, nextHandle.Interest.Time, nextStart.Format(time.RFC1123), nextWake.Format(time.RFC1123))
-				time.Sleep(sleepDuration)
-			} else {
-				if time.Since(nextWake) < bookingEarlyBuffer {
-					logf("Reached booking buffer for %s | %s | %s, polling until booking opens", nextHandle.Club, nextHandle.Interest.Day, nextHandle.Interest.Time)
-				} else {
-					logf("Booking window already open for %s | %s | %s, attempting immediately", nextHandle.Club, nextHandle.Interest.Day, nextHandle.Interest.Time)
-				}
-			}
+	return runAccountLoops(ctx, cfg, accounts, location)
+}
+
+// runAccountLoops runs a Scheduler (and reminders, when enabled) per account until every loop stops or ctx is
+// cancelled.
+func runAccountLoops(ctx context.Context, cfg *Config, accounts []*account, location *time.Location) error {
+	var (
+		wg     sync.WaitGroup
+		errsMu sync.Mutex
+		errs   []error
+	)
+
+	remindersCtx, stopReminders := context.WithCancel(ctx)
+	defer stopReminders()
+
+	for _, acct := range accounts {
+		acct.watchSchedule = true
+		if cfg.Reminders.Enabled() {
+			go runReminders(remindersCtx, acct, cfg.Reminders, location)
 		}
+
+		wg.Add(1)
+		go func(acct *account) {
+			defer wg.Done()
+			defer acct.flushNotifications()
+			if err := newScheduler(acct, location).Run(ctx); err != nil {
+				acct.log.Error("schedule loop stopped", "error", err)
+				errsMu.Lock()
+				errs = append(errs, fmt.Errorf("profile %s: %w", acct.profile.Name, err))
+				errsMu.Unlock()
+			}
+		}(acct)
 	}
+	wg.Wait()
+
+	return errors.Join(errs...)
 }
 
 func interestSatisfied(handle *scheduledInterest, results []interestResult) bool {
@@ -237,118 +320,175 @@ func interestSatisfied(handle *scheduledInterest, results []interestResult) bool
 	return false
 }
 
-func initSentry(dsn string) (bool, error) {
-	if dsn == "" {
-		return false, nil
-	}
-
-	if err := sentry.Init(sentry.ClientOptions{Dsn: dsn}); err != nil {
-		return false, fmt.Errorf("sentry init: %w", err)
-	}
-
-	return true, nil
-}
-
-func reportLoopError(enabled bool, err error, extras map[string]string) {
-	if !enabled || err == nil {
-		return
-	}
-
-	sentry.WithScope(func(scope *sentry.Scope) {
-		scope.SetTag("mode", "loop")
-		for k, v := range extras {
-			scope.SetTag(k, v)
+func interestBlocked(handle *scheduledInterest, results []interestResult) string {
+	for _, res := range results {
+		if res.ClubName == handle.Club && interestsEqual(res.Interest, handle.Interest) && res.Status == statusBlocked {
+			return res.Reason
 		}
-		sentry.CaptureException(err)
-	})
-}
-
-func reportLoopSuccess(enabled bool, classInfo Class) {
-	if !enabled {
-		return
 	}
-
-	sentry.WithScope(func(scope *sentry.Scope) {
-		scope.SetTag("mode", "loop")
-		scope.SetTag("club", classInfo.ClubName)
-		scope.SetTag("title", classInfo.Title)
-		scope.SetTag("time", classInfo.Time)
-		sentry.CaptureMessage("class booked successfully")
-	})
+	return ""
 }
 
-func scheduleInterests(ctx context.Context, client *WorldClassClient, cfg *Config, interests map[string][]ClassInterest, sentryEnabled bool) ([]interestResult, error) {
-	classes, err := client.FetchClasses(ctx, cfg.Credentials, cfg.Clubs)
+// scheduleInterests fetches the schedule once and tries to book every interest, walking each fallback chain in
+// order. windowOpen is when the primary class became bookable; fallbacks wait for their fallback_after delay
+// measured from it, and a zero windowOpen allows them immediately.
+func scheduleInterests(ctx context.Context, acct *account, interests map[string][]ClassInterest, windowOpen time.Time) ([]interestResult, error) {
+	clubs := acct.scheduleClubs()
+	now := acct.clock.Now().In(acct.location)
+	addBreadcrumb(acct.hub, "fetch", fmt.Sprintf("fetching schedules for %d clubs", len(clubs)), nil)
+	classes, err := acct.fetcher.FetchClasses(ctx, acct.profile.Credentials, clubs)
 	if err != nil {
 		return nil, err
 	}
 
 	results := make([]interestResult, 0)
-	var bookSession *bookingSession
+	var bookSession BookingSession
 	matches := 0
+	// matched is the class the latest attempt looked at.
+	var matched Class
 
-	for _, clubName := range sortedKeys(interests) {
-		for _, interest := range interests[clubName] {
-			res := interestResult{ClubName: clubName, Interest: interest, Status: statusNoMatch}
-			classInfo, found := findMatchingClass(classes, clubName, interest)
-			if !found {
-				results = append(results, res)
-				continue
-			}
+	attemptClass := func(classInfo Class, interest ClassInterest) (interestStatus, string, error) {
+		group := len(interest.With) > 0
 
-			matches++
+		switch {
+		case classInfo.Booked && !group:
+			acct.log.Info("already booked", classAttrs(classInfo)...)
+			return statusAlreadyBooked, "", nil
+		case !classInfo.Bookable && !classInfo.Booked:
+			acct.log.Info("booking not open yet", classAttrs(classInfo)...)
+			return statusNotOpen, "", nil
+		}
 
-			switch {
-			case classInfo.Booked:
-				logf("Already booked: %s | %s | %s | %s | Trainer: %s | ClassID: %s", classInfo.ClubName, classInfo.Day, classInfo.Time, classInfo.Title, classInfo.Trainer, classInfo.ClassID)
-				res.Status = statusAlreadyBooked
-				results = append(results, res)
-				continue
-			case !classInfo.Bookable:
-				logf("Booking not open yet: %s | %s | %s | Trainer: %s | Title: %s", classInfo.ClubName, classInfo.Day, classInfo.Time, classInfo.Trainer, classInfo.Title)
-				res.Status = statusNotOpen
-				results = append(results, res)
-				continue
-			}
+		if classInfo.ClassID == "" {
+			acct.log.Warn("skipping class: missing class identifier", classAttrs(classInfo)...)
+			return statusMissingData, "", nil
+		}
 
-			if classInfo.ClassID == "" {
-				logf("Skipping %s | %s | %s | %s: missing class identifier", classInfo.ClubName, classInfo.Day, classInfo.Time, classInfo.Title)
-				res.Status = statusMissingData
-				results = append(results, res)
-				continue
+		if classInfo.ClubID == "" {
+			acct.log.Warn("skipping class: missing club identifier", classAttrs(classInfo)...)
+			return statusMissingData, "", nil
+		}
+
+		if !classInfo.Booked {
+			if reason := checkBookingRules(acct.rules, acct.clubRules, classes, classInfo, now); reason != "" {
+				acct.log.Info("skipping class: blocked by booking rules", append(classAttrs(classInfo), "reason", reason)...)
+				return statusBlocked, reason, nil
 			}
+		}
 
-			if classInfo.ClubID == "" {
-				logf("Skipping %s | %s | %s | %s: missing club identifier", classInfo.ClubName, classInfo.Day, classInfo.Time, classInfo.Title)
-				res.Status = statusMissingData
-				results = append(results, res)
-				continue
+		if acct.dryRun {
+			acct.log.Info("dry run: would book", append(classAttrs(classInfo), "members", 1+len(interest.With))...)
+			markBooked(classes, classInfo)
+			return statusWouldBook, "", nil
+		}
+
+		if group {
+			status, reason := bookGroup(ctx, acct, classInfo, interest)
+			if status == statusBooked {
+				markBooked(classes, classInfo)
 			}
+			return status, reason, nil
+		}
 
-			if bookSession == nil {
-				bookSession, err = client.newBookingSession(ctx, cfg.Credentials)
-				if err != nil {
-					return nil, fmt.Errorf("start booking session: %w", err)
-				}
+		if bookSession == nil {
+			addBreadcrumb(acct.hub, "login", "starting booking session", nil)
+			bookSession, err = acct.booker.Login(ctx, acct.profile.Credentials)
+			if err != nil {
+				return statusBookingFailed, "", fmt.Errorf("start booking session: %w", err)
 			}
+		}
+
+		acct.log.Info("scheduling attempt", append(classAttrs(classInfo), "phase", "book")...)
+
+		addBreadcrumb(acct.hub, "book", "booking class", &classInfo)
+		if err := bookSession.Book(ctx, classInfo.ClubID, classInfo.ClassID); err != nil {
+			acct.log.Error("failed booking", append(classAttrs(classInfo), "phase", "book", "error", err)...)
+			acct.notifyFailure(classInfo, "booking failed: %v", err)
+			return statusBookingFailed, "", nil
+		}
 
-			logf("Scheduling attempt: %s | %s | %s | %s | ClassID: %s", classInfo.ClubName, classInfo.Day, classInfo.Time, classInfo.Title, classInfo.ClassID)
+		acct.log.Info("booked successfully", append(classAttrs(classInfo), "phase", "book")...)
+		reportLoopSuccess(acct.hub, classInfo)
+		acct.notify(EventBooked, classInfo, "class booked successfully")
+		acct.recordBooking(acct.profile.Name, historyBooked, classInfo, sourceLoop)
+		markBooked(classes, classInfo)
+		return statusBooked, "", nil
+	}
+
+	// attempt tries every class matching interest in order of preference: with start ranges or day lists
+	// several classes can match, and a full or blocked one must not hide a later one that can be booked.
+	attempt := func(clubName string, interest ClassInterest) (interestStatus, string, error) {
+		candidates := findMatchingClasses(classes, clubName, interest, now)
+		matched = Class{}
+		if len(candidates) == 0 {
+			return statusNoMatch, "", nil
+		}
+
+		matches++
 
-			success, err := bookSession.BookClass(ctx, classInfo.ClubID, classInfo.ClassID)
+		bestStatus, bestReason := statusNoMatch, ""
+		for _, classInfo := range candidates {
+			status, reason, err := attemptClass(classInfo, interest)
 			if err != nil {
-				logf("Failed booking: %s | %s | %s | %s | error: %v", classInfo.ClubName, classInfo.Day, classInfo.Time, classInfo.Title, err)
-				res.Status = statusBookingFailed
+				matched = classInfo
+				return status, reason, err
+			}
+			if bestStatus == statusNoMatch || status.rank() < bestStatus.rank() {
+				bestStatus, bestReason, matched = status, reason, classInfo
+			}
+			if status.settled() {
+				break
+			}
+		}
+		return bestStatus, bestReason, nil
+	}
+
+	for _, clubName := range sortedKeys(interests) {
+		for _, interest := range interests[clubName] {
+			res := interestResult{ClubName: clubName, Interest: interest, Status: statusNoMatch}
+			chain := fallbackChain(clubName, interest)
+
+			// A fallback booked by an earlier attempt already satisfies the interest; booking the
+			// primary on top of it would leave us with two reservations.
+			if link, classInfo, ok := bookedFallback(classes, chain, now); ok {
+				matches++
+				acct.log.Info("fallback already booked", append(classAttrs(classInfo), "fallback", link)...)
+				res.Status = statusAlreadyBooked
+				res.Class = classInfo
 				results = append(results, res)
 				continue
 			}
 
-			if success {
-				logf("Booked successfully: %s | %s | %s | %s | ClassID: %s", classInfo.ClubName, classInfo.Day, classInfo.Time, classInfo.Title, classInfo.ClassID)
-				res.Status = statusBooked
-				reportLoopSuccess(sentryEnabled, classInfo)
-			} else {
-				logf("Booking attempted but not confirmed: %s | %s | %s | %s | ClassID: %s", classInfo.ClubName, classInfo.Day, classInfo.Time, classInfo.Title, classInfo.ClassID)
-				res.Status = statusBookingFailed
+			for idx, link := range chain {
+				if idx > 0 {
+					// Without a window (one-shot runs and dry runs) a primary that is not open yet still has its
+					// window ahead, so booking a fallback now would give up on it too early.
+					if windowOpen.IsZero() && res.Status == statusNotOpen {
+						acct.log.Info("primary class not open yet; not trying fallbacks", interestAttrs(clubName, interest)...)
+						break
+					}
+					// A primary blocked by a rule will not become bookable, so its fallbacks need not wait.
+					if due := fallbackDue(interest, windowOpen); res.Status != statusBlocked && acct.clock.Now().Before(due) {
+						acct.log.Info("primary class not booked yet; waiting before fallbacks", append(interestAttrs(clubName, interest), "fallbacks_from", due.Format(time.RFC1123))...)
+						break
+					}
+					acct.log.Info("trying fallback", append(interestAttrs(clubName, interest), "fallback", idx, "fallback_club", link.club, "fallback_interest", link.interest.describe())...)
+				}
+
+				status, reason, err := attempt(link.club, link.interest)
+				if err != nil {
+					return nil, err
+				}
+
+				// A fallback that may still be booked outranks a blocked primary, so the window stays open for it.
+				if idx == 0 || res.Status == statusNoMatch || status.rank() < res.Status.rank() {
+					res.Status = status
+					res.Reason = reason
+					res.Class = matched
+				}
+				if status.settled() {
+					break
+				}
 			}
 
 			results = append(results, res)
@@ -356,22 +496,13 @@ func scheduleInterests(ctx context.Context, client *WorldClassClient, cfg *Confi
 	}
 
 	if matches == 0 {
-		logf("no classes matched your filters")
+		acct.log.Info("no classes matched your filters")
 	}
 
 	return results, nil
 }
 
-func logf(format string, args ...interface{}) {
-	now := time.Now().Format(time.DateTime)
-	fmt.Printf("[%s] %s\n", now, fmt.Sprintf(format, args...))
-}
-
-func filterClassesForInterests(classes []Class, interests map[string][]ClassInterest, logger func(string, ...interface{})) []Class {
-	if logger == nil {
-		logger = func(string, ...interface{}) {}
-	}
-
+func filterClassesForInterests(classes []Class, interests map[string][]ClassInterest, reference time.Time, logger *slog.Logger) []Class {
 	var filtered []Class
 	for _, classInfo := range classes {
 		interestList, ok := interests[classInfo.ClubName]
@@ -380,7 +511,7 @@ func filterClassesForInterests(classes []Class, interests map[string][]ClassInte
 		}
 
 		for _, interest := range interestList {
-			if interestMatches(classInfo, interest, logger) {
+			if interestMatches(classInfo, interest, reference, logger) {
 				filtered = append(filtered, classInfo)
 				break
 			}
@@ -390,7 +521,7 @@ func filterClassesForInterests(classes []Class, interests map[string][]ClassInte
 	return filtered
 }
 
-func interestMatches(classInfo Class, interest ClassInterest, logger func(string, ...interface{})) bool {
+func interestMatches(classInfo Class, interest ClassInterest, reference time.Time, logger *slog.Logger) bool {
 	normalizedDay := strings.ToLower(strings.TrimSpace(classInfo.Day))
 	dayNeedle := strings.ToLower(strings.TrimSpace(interest.Day))
 	if dayNeedle != "" && !strings.Contains(normalizedDay, dayNeedle) {
@@ -403,10 +534,14 @@ func interestMatches(classInfo Class, interest ClassInterest, logger func(string
 		return false
 	}
 
+	if !extendedFiltersMatch(classInfo, interest, reference) {
+		return false
+	}
+
 	titleNeedleRaw := strings.TrimSpace(interest.Title)
 	if titleNeedleRaw == "" {
-		if logger != nil {
-			logger("interest for %s on %s %s has no title filter; matching class %q", classInfo.ClubName, classInfo.Day, classInfo.Time, classInfo.Title)
+		if logger != nil && interest.TitleRegex == "" {
+			logger.Debug("interest has no title filter; matching class", classAttrs(classInfo)...)
 		}
 		return true
 	}
@@ -420,7 +555,7 @@ func interestMatches(classInfo Class, interest ClassInterest, logger func(string
 
 	if strings.Contains(classTitle, titleNeedle) {
 		if logger != nil {
-			logger("interest title %q partially matched class title %q for %s on %s %s", interest.Title, classInfo.Title, classInfo.ClubName, classInfo.Day, classInfo.Time)
+			logger.Debug("interest title partially matched class title", append(classAttrs(classInfo), "interest_title", interest.Title)...)
 		}
 		return true
 	}
@@ -437,55 +572,158 @@ const (
 	statusBooked
 	statusBookingFailed
 	statusMissingData
+	statusBlocked
+	statusWouldBook
 )
 
+func (s interestStatus) String() string {
+	switch s {
+	case statusNoMatch:
+		return "no match"
+	case statusAlreadyBooked:
+		return "already booked"
+	case statusNotOpen:
+		return "not open"
+	case statusBooked:
+		return "booked"
+	case statusBookingFailed:
+		return "booking failed"
+	case statusMissingData:
+		return "missing data"
+	case statusBlocked:
+		return "blocked by rule"
+	case statusWouldBook:
+		return "book"
+	default:
+		return "unknown"
+	}
+}
+
+// settled reports whether the status ends the attempts for an interest: it holds a booking, or a dry run
+// would make one.
+func (s interestStatus) settled() bool {
+	return s == statusBooked || s == statusAlreadyBooked || s == statusWouldBook
+}
+
+// rank orders the outcomes of several candidate classes: a booking first, then outcomes worth retrying, then
+// the ones that end the window.
+func (s interestStatus) rank() int {
+	switch s {
+	case statusBooked, statusAlreadyBooked, statusWouldBook:
+		return 0
+	case statusBookingFailed:
+		return 1
+	case statusNotOpen:
+		return 2
+	case statusBlocked:
+		return 3
+	default:
+		return 4
+	}
+}
+
 type interestResult struct {
 	ClubName string
 	Interest ClassInterest
 	Status   interestStatus
+	// Reason explains why a booking was skipped when Status is statusBlocked.
+	Reason string
+	// Class is the scraped class that produced Status; it is zero when nothing matched.
+	Class Class
 }
 
 type scheduledInterest struct {
 	Club     string
 	Interest ClassInterest
+	// LastStart is the latest start time accepted for this occurrence; it equals the occurrence for fixed-time interests.
+	LastStart time.Time
 }
 
 var errNoInterests = errors.New("no class interests configured")
 
-func findMatchingClass(classes []Class, clubName string, interest ClassInterest) (Class, bool) {
+// fetchCancelLink re-fetches classInfo's club for creds and returns the cancel link of the class, which only
+// the schedule of the account holding the booking carries.
+func fetchCancelLink(ctx context.Context, fetcher Fetcher, creds Credentials, classInfo Class) (string, error) {
+	classes, err := fetcher.FetchClasses(ctx, creds, []Club{{ID: classInfo.ClubID, Name: classInfo.ClubName}})
+	if err != nil {
+		return "", fmt.Errorf("fetch classes: %w", err)
+	}
+	for _, candidate := range classes {
+		if candidate.ClubID == classInfo.ClubID && candidate.ClassID == classInfo.ClassID && candidate.CancelURL != "" {
+			return candidate.CancelURL, nil
+		}
+	}
+	return "", wc.ErrNoCancelLink
+}
+
+// findMatchingClass returns the preferred class of clubName that matches interest (see findMatchingClasses).
+func findMatchingClass(classes []Class, clubName string, interest ClassInterest, reference time.Time) (Class, bool) {
+	matches := findMatchingClasses(classes, clubName, interest, reference)
+	if len(matches) == 0 {
+		return Class{}, false
+	}
+	return matches[0], true
+}
+
+// findMatchingClasses returns every class of clubName that matches interest: booked classes first, then
+// bookable ones, then the rest, each in schedule order.
+func findMatchingClasses(classes []Class, clubName string, interest ClassInterest, reference time.Time) []Class {
+	var matches []Class
 	for _, classInfo := range classes {
 		if classInfo.ClubName != clubName {
 			continue
 		}
-		if interestMatches(classInfo, interest, nil) {
-			return classInfo, true
+		if interestMatches(classInfo, interest, reference, nil) {
+			matches = append(matches, classInfo)
 		}
 	}
-	return Class{}, false
+	sort.SliceStable(matches, func(i, j int) bool {
+		return classPreference(matches[i]) < classPreference(matches[j])
+	})
+	return matches
+}
+
+func classPreference(classInfo Class) int {
+	switch {
+	case classInfo.Booked:
+		return 0
+	case classInfo.Bookable:
+		return 1
+	default:
+		return 2
+	}
 }
 
-func nextInterestOccurrence(cfg *Config, loc *time.Location, reference time.Time) (*scheduledInterest, time.Time, error) {
+func nextInterestOccurrence(interests map[string][]ClassInterest, loc *time.Location, reference time.Time) (*scheduledInterest, time.Time, error) {
 	var (
 		nextHandle *scheduledInterest
 		nextTime   time.Time
 	)
 
-	for _, clubName := range sortedKeys(cfg.Interests) {
-		for _, interest := range cfg.Interests[clubName] {
-			weekday, err := parseWeekday(interest.DayEnglish)
+	for _, clubName := range sortedKeys(interests) {
+		for _, interest := range interests[clubName] {
+			weekdays, err := interestWeekdays(interest)
 			if err != nil {
 				return nil, time.Time{}, fmt.Errorf("parse weekday for %s (%s): %w", clubName, interest.Title, err)
 			}
 
-			hour, minute, err := parseStartTime(interest.Time)
+			earliest, latest, err := interestStartWindow(interest)
 			if err != nil {
 				return nil, time.Time{}, fmt.Errorf("parse time for %s (%s): %w", clubName, interest.Title, err)
 			}
 
-			occurrence := computeNextOccurrence(reference, loc, weekday, hour, minute)
-			if nextHandle == nil || occurrence.Before(nextTime) {
-				nextHandle = &scheduledInterest{Club: clubName, Interest: interest}
-				nextTime = occurrence
+			for _, weekday := range weekdays {
+				occurrence, ok, err := nextActiveOccurrence(interest, computeNextOccurrence(reference, loc, weekday, earliest/60, earliest%60), earliest)
+				if err != nil {
+					return nil, time.Time{}, fmt.Errorf("dates for %s (%s): %w", clubName, interest.Title, err)
+				}
+				if !ok {
+					continue
+				}
+				if nextHandle == nil || occurrence.Before(nextTime) {
+					nextHandle = newScheduledInterest(clubName, interest, weekday, len(weekdays) > 1, occurrence, latest-earliest)
+					nextTime = occurrence
+				}
 			}
 		}
 	}
@@ -497,6 +735,23 @@ func nextInterestOccurrence(cfg *Config, loc *time.Location, reference time.Time
 	return nextHandle, nextTime, nil
 }
 
+// newScheduledInterest builds the handle for one occurrence. Multi-day interests are narrowed to the chosen
+// weekday so the booking attempt cannot be satisfied by a class on another day.
+func newScheduledInterest(clubName string, interest ClassInterest, weekday time.Weekday, multiDay bool, occurrence time.Time, spanMinutes int) *scheduledInterest {
+	narrowed := interest
+	if multiDay {
+		// Day holds one Romanian day name, which would keep matching only that day.
+		narrowed.Day = ""
+		narrowed.DayEnglish = weekday.String()
+		narrowed.Days = []string{weekday.String()}
+	}
+	return &scheduledInterest{
+		Club:      clubName,
+		Interest:  narrowed,
+		LastStart: occurrence.Add(time.Duration(spanMinutes) * time.Minute),
+	}
+}
+
 func parseWeekday(day string) (time.Weekday, error) {
 	switch strings.ToLower(strings.TrimSpace(day)) {
 	case "sunday":
@@ -551,7 +806,7 @@ func computeNextOccurrence(reference time.Time, loc *time.Location, weekday time
 	return start
 }
 
-func sortedKeys(m map[string][]ClassInterest) []string {
+func sortedKeys[V any](m map[string]V) []string {
 	keys := make([]string, 0, len(m))
 	for k := range m {
 		keys = append(keys, k)
@@ -564,5 +819,17 @@ func interestsEqual(a, b ClassInterest) bool {
 	return a.Day == b.Day &&
 		a.DayEnglish == b.DayEnglish &&
 		a.Time == b.Time &&
-		a.Title == b.Title
+		a.Title == b.Title &&
+		a.TitleRegex == b.TitleRegex &&
+		a.Trainer == b.Trainer &&
+		a.Room == b.Room &&
+		a.StartAfter == b.StartAfter &&
+		a.StartBefore == b.StartBefore &&
+		strings.EqualFold(a.Category, b.Category) &&
+		a.Date == b.Date &&
+		a.From == b.From &&
+		a.Until == b.Until &&
+		slices.Equal(a.Days, b.Days) &&
+		slices.Equal(a.Exclude, b.Exclude) &&
+		slices.Equal(a.Skip, b.Skip)
 }
@@ -0,0 +1,172 @@
+package worldclass
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestScheduleInterestsTriesEveryMatchingClass(t *testing.T) {
+	interest := ClassInterest{Title: "PILATES", DayEnglish: "Monday", StartAfter: "17:00", StartBefore: "20:00"}
+	full := Class{ClubID: "454", ClubName: "Park Lake", Day: "Luni 19.10", Time: "17:00 - 18:00", Title: "PILATES", ClassID: "1"}
+	open := Class{ClubID: "454", ClubName: "Park Lake", Day: "Luni 19.10", Time: "18:00 - 19:00", Title: "PILATES", ClassID: "2", Bookable: true}
+	later := Class{ClubID: "454", ClubName: "Park Lake", Day: "Luni 19.10", Time: "19:00 - 20:00", Title: "PILATES", ClassID: "3", Bookable: true}
+
+	tests := []struct {
+		name       string
+		classes    []Class
+		bookErr    map[string]error
+		wantStatus interestStatus
+		wantBooked []string
+	}{
+		{
+			name:       "full class listed first",
+			classes:    []Class{full, open},
+			wantStatus: statusBooked,
+			wantBooked: []string{"2"},
+		},
+		{
+			name:       "first bookable class refuses the booking",
+			classes:    []Class{open, later},
+			bookErr:    map[string]error{"2": errors.New("class is full")},
+			wantStatus: statusBooked,
+			wantBooked: []string{"3"},
+		},
+		{
+			name:       "nothing bookable yet",
+			classes:    []Class{full},
+			wantStatus: statusNotOpen,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			booker := &fakeBooker{bookErr: tt.bookErr}
+			acct := newTestAccount(&fakeFetcher{classes: tt.classes}, booker)
+
+			results, err := scheduleInterests(context.Background(), acct, map[string][]ClassInterest{"Park Lake": {interest}}, time.Time{})
+			if err != nil {
+				t.Fatalf("scheduleInterests: %v", err)
+			}
+			if len(results) != 1 || results[0].Status != tt.wantStatus {
+				t.Fatalf("results = %+v, want status %v", results, tt.wantStatus)
+			}
+			if got := booker.bookedIDs(); !slices.Equal(got, tt.wantBooked) {
+				t.Errorf("booked %v, want %v", got, tt.wantBooked)
+			}
+		})
+	}
+}
+
+func TestNewScheduledInterestNarrowsToWeekday(t *testing.T) {
+	interest := ClassInterest{Day: "Luni", DayEnglish: "Monday", Days: []string{"Wednesday"}, Title: "BODYPUMP"}
+	wednesday := Class{ClubName: "Park Lake", Day: "Miercuri 21.10", Time: "17:00 - 18:00", Title: "BODYPUMP"}
+	monday := Class{ClubName: "Park Lake", Day: "Luni 19.10", Time: "17:00 - 18:00", Title: "BODYPUMP"}
+
+	handle := newScheduledInterest("Park Lake", interest, time.Wednesday, true, time.Date(2026, 10, 21, 17, 0, 0, 0, time.UTC), 0)
+	if !interestMatches(wednesday, handle.Interest, handle.LastStart, nil) {
+		t.Errorf("narrowed interest %+v does not match the Wednesday class", handle.Interest)
+	}
+	if interestMatches(monday, handle.Interest, handle.LastStart, nil) {
+		t.Errorf("narrowed interest %+v matches the Monday class", handle.Interest)
+	}
+}
+
+func TestScheduleInterestsWaitsForPrimaryWindow(t *testing.T) {
+	interest := ClassInterest{
+		Title:      "PILATES",
+		DayEnglish: "Monday",
+		Time:       "18:00 - 19:00",
+		Fallbacks:  []ClassInterest{{Title: "YOGA", DayEnglish: "Monday", Time: "19:00 - 20:00"}},
+	}
+	fallback := Class{ClubID: "454", ClubName: "Park Lake", Day: "Luni 19.10", Time: "19:00 - 20:00", Title: "YOGA", ClassID: "2", Bookable: true}
+
+	tests := []struct {
+		name       string
+		primary    Class
+		wantStatus interestStatus
+		wantBooked []string
+	}{
+		{
+			name:       "primary not open",
+			primary:    Class{ClubID: "454", ClubName: "Park Lake", Day: "Luni 19.10", Time: "18:00 - 19:00", Title: "PILATES", ClassID: "1"},
+			wantStatus: statusNotOpen,
+		},
+		{
+			name:       "primary refused",
+			primary:    Class{ClubID: "454", ClubName: "Park Lake", Day: "Luni 19.10", Time: "18:00 - 19:00", Title: "PILATES", ClassID: "1", Bookable: true},
+			wantStatus: statusBooked,
+			wantBooked: []string{"2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			booker := &fakeBooker{bookErr: map[string]error{"1": errors.New("class is full")}}
+			acct := newTestAccount(&fakeFetcher{classes: []Class{tt.primary, fallback}}, booker)
+
+			results, err := scheduleInterests(context.Background(), acct, map[string][]ClassInterest{"Park Lake": {interest}}, time.Time{})
+			if err != nil {
+				t.Fatalf("scheduleInterests: %v", err)
+			}
+			if len(results) != 1 || results[0].Status != tt.wantStatus {
+				t.Fatalf("results = %+v, want status %v", results, tt.wantStatus)
+			}
+			if got := booker.bookedIDs(); !slices.Equal(got, tt.wantBooked) {
+				t.Errorf("booked %v, want %v", got, tt.wantBooked)
+			}
+		})
+	}
+}
+
+func TestValidateFallbackClubs(t *testing.T) {
+	clubs := []Club{{ID: "454", Name: "Park Lake"}, {ID: "458", Name: "Titan Park"}}
+	tests := []struct {
+		name    string
+		club    string
+		wantErr bool
+	}{
+		{name: "same club", club: ""},
+		{name: "configured club", club: "Titan Park"},
+		{name: "typo", club: "Titan Prak", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interest := ClassInterest{Title: "PILATES", Fallbacks: []ClassInterest{{Title: "YOGA", Club: tt.club}}}
+			if err := validateFallbackClubs(interest, clubs); (err != nil) != tt.wantErr {
+				t.Errorf("validateFallbackClubs() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScheduleInterestsKeepsWindowOpenForFallback(t *testing.T) {
+	interest := ClassInterest{
+		Title:      "PILATES",
+		DayEnglish: "Monday",
+		Time:       "18:00 - 19:00",
+		Fallbacks:  []ClassInterest{{Title: "YOGA", DayEnglish: "Monday", Time: "20:00 - 21:00"}},
+	}
+	classes := []Class{
+		{ClubID: "454", ClubName: "Park Lake", Day: "Luni 19.10", Time: "17:30 - 18:30", Title: "SPINNING", ClassID: "1", Booked: true},
+		{ClubID: "454", ClubName: "Park Lake", Day: "Luni 19.10", Time: "18:00 - 19:00", Title: "PILATES", ClassID: "2", Bookable: true},
+		{ClubID: "454", ClubName: "Park Lake", Day: "Luni 19.10", Time: "20:00 - 21:00", Title: "YOGA", ClassID: "3"},
+	}
+	acct := newTestAccount(&fakeFetcher{classes: classes}, &fakeBooker{})
+	acct.rules = BookingRules{NoOverlap: true}
+
+	results, err := scheduleInterests(context.Background(), acct, map[string][]ClassInterest{"Park Lake": {interest}}, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("scheduleInterests: %v", err)
+	}
+	handle := &scheduledInterest{Club: "Park Lake", Interest: interest}
+	if reason := interestBlocked(handle, results); reason != "" {
+		t.Errorf("window ended with %q although the fallback may still open", reason)
+	}
+	if len(results) != 1 || results[0].Status != statusNotOpen {
+		t.Errorf("results = %+v, want the fallback's not open status", results)
+	}
+}
@@ -4,23 +4,66 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"time"
 
 	"github.com/goccy/go-yaml"
+	wc "github.com/tatulea/worldclass-scheduler/pkg/worldclass"
+)
+
+// The scraping types live in the public package; the aliases keep the config and commands readable.
+type (
+	Credentials = wc.Credentials
+	Club        = wc.Club
+	Group       = wc.Group
+	Class       = wc.Class
 )
 
 const (
-	defaultBaseURL = "https://members.worldclass.ro"
-	defaultTZ      = "Europe/Bucharest"
+	defaultBaseURL     = "https://members.worldclass.ro"
+	defaultTZ          = "Europe/Bucharest"
+	defaultProfileName = "default"
+
+	groupPolicyAllOrNothing = "all-or-nothing"
+	groupPolicyBestEffort   = "best-effort"
 )
 
 // Config captures runtime settings loaded from config.yaml.
 type Config struct {
-	BaseURL     string                         `yaml:"base_url"`
-	Timezone    string                         `yaml:"timezone"`
-	Credentials Credentials                    `yaml:"credentials"`
-	Clubs       []Club                         `yaml:"clubs"`
-	Interests   map[string][]ClassInterest     `yaml:"interests"`
-	Sentry      SentryConfig                   `yaml:"sentry"`
+	BaseURL       string                     `yaml:"base_url"`
+	Timezone      string                     `yaml:"timezone"`
+	Credentials   Credentials                `yaml:"credentials"`
+	Clubs         []Club                     `yaml:"clubs"`
+	Interests     map[string][]ClassInterest `yaml:"interests"`
+	Profiles      []Profile                  `yaml:"profiles"`
+	Rules         BookingRules               `yaml:"rules"`
+	ClubRules     map[string]BookingRules    `yaml:"club_rules"`
+	Notifications NotificationsConfig        `yaml:"notifications"`
+	Reminders     RemindersConfig            `yaml:"reminders"`
+	Sentry        SentryConfig               `yaml:"sentry"`
+	Dashboard     DashboardConfig            `yaml:"dashboard"`
+	Health        HealthConfig               `yaml:"health"`
+	Pause         PauseConfig                `yaml:"pause"`
+	// StateDir holds the history and statistics files; relative paths, and the worldclass-state default,
+	// are resolved next to the config file.
+	StateDir string `yaml:"state_dir"`
+	// Skip lists dates or date ranges without bookings for every profile; SkipCalendars names .ics files
+	// (relative to the config file) whose events are imported as such dates.
+	Skip          []string `yaml:"skip"`
+	SkipCalendars []string `yaml:"skip_calendars"`
+}
+
+// Profile groups the credentials, clubs and interests of a single member account.
+type Profile struct {
+	Name        string                     `yaml:"name"`
+	Credentials Credentials                `yaml:"credentials"`
+	Clubs       []Club                     `yaml:"clubs"`
+	Interests   map[string][]ClassInterest `yaml:"interests"`
+	// Skip and SkipCalendars add dates without bookings (holidays, vacations) to every interest of the profile,
+	// on top of the top-level lists.
+	Skip          []string `yaml:"skip"`
+	SkipCalendars []string `yaml:"skip_calendars"`
 }
 
 // ClassInterest describes a class the user is interested in tracking or booking.
@@ -29,15 +72,72 @@ type ClassInterest struct {
 	Time       string `yaml:"time"`
 	Title      string `yaml:"title"`
 	DayEnglish string `yaml:"day_english"`
+	// TitleRegex is an optional case-insensitive regular expression the class title must match.
+	TitleRegex string `yaml:"title_regex"`
+	// Trainer and Room are optional case-insensitive substrings.
+	Trainer string `yaml:"trainer"`
+	Room    string `yaml:"room"`
+	// StartAfter and StartBefore bound the class start time (HH:MM, inclusive) when Time is not fixed.
+	StartAfter  string `yaml:"start_after"`
+	StartBefore string `yaml:"start_before"`
+	// Days lists English weekday names, or "weekdays"/"weekend", on top of DayEnglish.
+	Days []string `yaml:"days"`
+	// Category restricts matches to a schedule group (e.g. "Cardio"); the club is then fetched per category.
+	Category string `yaml:"category"`
+	// Exclude lists case-insensitive regular expressions; a class whose title, trainer or room matches any is skipped.
+	Exclude []string `yaml:"exclude"`
+	// Fallbacks are tried in order when the primary class cannot be booked; each may target another Club.
+	Fallbacks []ClassInterest `yaml:"fallbacks"`
+	// FallbackAfter is how long after the booking window opens to keep trying the primary alone (e.g. "5m").
+	FallbackAfter string `yaml:"fallback_after"`
+	// Club overrides the club of a fallback entry; it is ignored on top-level interests.
+	Club string `yaml:"club"`
+	// With lists other profiles that should be booked into the same class alongside the owner.
+	With []string `yaml:"with"`
+	// GroupPolicy is either "all-or-nothing" (default) or "best-effort".
+	GroupPolicy string `yaml:"group_policy"`
+	// Date (YYYY-MM-DD) makes the interest a one-off booking for that day; the weekday follows from it.
+	Date string `yaml:"date"`
+	// From and Until (YYYY-MM-DD, inclusive) limit a weekly interest to a season.
+	From  string `yaml:"from"`
+	Until string `yaml:"until"`
+	// Skip lists dates (YYYY-MM-DD) or ranges (YYYY-MM-DD..YYYY-MM-DD) on which the interest is not booked.
+	Skip []string `yaml:"skip"`
+
+	// skipRanges holds the profile's skip dates and calendars, filled in when the config is loaded.
+	skipRanges []dateRange
+}
+
+// DashboardConfig configures the web dashboard started by the serve command.
+type DashboardConfig struct {
+	// Address is the listen address, 127.0.0.1:8080 by default.
+	Address string `yaml:"address"`
+	// Password protects every page; the dashboard refuses to start without one.
+	Password string `yaml:"password"`
 }
 
 // SentryConfig groups the optional monitoring settings.
 type SentryConfig struct {
-	DSN string `yaml:"dsn"`
+	DSN         string `yaml:"dsn"`
+	Release     string `yaml:"release"`
+	Environment string `yaml:"environment"`
+	ServerName  string `yaml:"server_name"`
+	// DisableCheckIns turns off the Cron Monitor check-ins sent for each booking window in loop mode.
+	DisableCheckIns bool `yaml:"disable_check_ins"`
 }
 
 // LoadConfig reads the YAML configuration file from disk.
 func LoadConfig(path string) (*Config, error) {
+	return loadConfig(path, true)
+}
+
+// LoadConfigForDiscovery reads the configuration like LoadConfig but accepts profiles without clubs,
+// so the clubs can be discovered from the member site first.
+func LoadConfigForDiscovery(path string) (*Config, error) {
+	return loadConfig(path, false)
+}
+
+func loadConfig(path string, requireClubs bool) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read config: %w", err)
@@ -57,13 +157,156 @@ func LoadConfig(path string) (*Config, error) {
 	if cfg.Interests == nil {
 		cfg.Interests = make(map[string][]ClassInterest)
 	}
+	if cfg.StateDir == "" {
+		cfg.StateDir = defaultStateDirName
+	}
+	if !filepath.IsAbs(cfg.StateDir) {
+		cfg.StateDir = filepath.Join(filepath.Dir(path), cfg.StateDir)
+	}
 
-	if cfg.Credentials.Email == "" || cfg.Credentials.Password == "" {
-		return nil, errors.New("credentials.email and credentials.password must be set")
+	if err := cfg.Rules.validate(); err != nil {
+		return nil, fmt.Errorf("rules: %w", err)
+	}
+	if err := cfg.Reminders.validate(); err != nil {
+		return nil, fmt.Errorf("reminders: %w", err)
+	}
+	if err := cfg.Health.validate(); err != nil {
+		return nil, fmt.Errorf("health: %w", err)
+	}
+	for club, rules := range cfg.ClubRules {
+		if err := rules.validate(); err != nil {
+			return nil, fmt.Errorf("club_rules %s: %w", club, err)
+		}
 	}
-	if len(cfg.Clubs) == 0 {
-		return nil, errors.New("at least one club must be configured")
+
+	// A config without profiles describes a single account through the top-level fields.
+	legacy := len(cfg.Profiles) == 0
+	if legacy {
+		cfg.Profiles = []Profile{{
+			Name:        defaultProfileName,
+			Credentials: cfg.Credentials,
+			Clubs:       cfg.Clubs,
+			Interests:   cfg.Interests,
+		}}
+	}
+
+	seen := make(map[string]bool, len(cfg.Profiles))
+	for i := range cfg.Profiles {
+		profile := &cfg.Profiles[i]
+		if profile.Name == "" {
+			return nil, fmt.Errorf("profiles[%d].name must be set", i)
+		}
+		if seen[profile.Name] {
+			return nil, fmt.Errorf("profile %q is defined more than once", profile.Name)
+		}
+		seen[profile.Name] = true
+
+		if len(profile.Clubs) == 0 {
+			profile.Clubs = cfg.Clubs
+		}
+		if profile.Interests == nil {
+			profile.Interests = make(map[string][]ClassInterest)
+		}
+
+		if profile.Credentials.Email == "" || profile.Credentials.Password == "" {
+			if legacy {
+				return nil, errors.New("credentials.email and credentials.password must be set")
+			}
+			return nil, fmt.Errorf("profile %q: credentials.email and credentials.password must be set", profile.Name)
+		}
+		if requireClubs && len(profile.Clubs) == 0 {
+			if legacy {
+				return nil, errors.New("at least one club must be configured")
+			}
+			return nil, fmt.Errorf("profile %q: at least one club must be configured", profile.Name)
+		}
+	}
+
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("load timezone %s: %w", cfg.Timezone, err)
+	}
+	for i := range cfg.Profiles {
+		profile := &cfg.Profiles[i]
+		skip, err := loadSkipDates(filepath.Dir(path), location, append(slices.Clone(cfg.Skip), profile.Skip...), append(slices.Clone(cfg.SkipCalendars), profile.SkipCalendars...))
+		if err != nil {
+			if legacy {
+				return nil, err
+			}
+			return nil, fmt.Errorf("profile %q: %w", profile.Name, err)
+		}
+		for _, interests := range profile.Interests {
+			for j := range interests {
+				interests[j].skipRanges = skip
+				for k := range interests[j].Fallbacks {
+					interests[j].Fallbacks[k].skipRanges = skip
+				}
+			}
+		}
+	}
+
+	for _, profile := range cfg.Profiles {
+		for clubName, interests := range profile.Interests {
+			for _, interest := range interests {
+				if err := validateGroupInterest(interest, seen); err != nil {
+					return nil, fmt.Errorf("profile %q: interest %s (%s): %w", profile.Name, clubName, interest.Title, err)
+				}
+				if err := validateInterestFilters(interest); err != nil {
+					return nil, fmt.Errorf("profile %q: interest %s (%s): %w", profile.Name, clubName, interest.Title, err)
+				}
+				if err := validateFallbackClubs(interest, profile.Clubs); err != nil {
+					return nil, fmt.Errorf("profile %q: interest %s (%s): %w", profile.Name, clubName, interest.Title, err)
+				}
+			}
+		}
 	}
 
 	return &cfg, nil
 }
+
+// validateFallbackClubs checks that every fallback club is one of the profile's clubs, since only those are
+// fetched. Profiles without clubs, which only occur while discovering clubs, are not checked.
+func validateFallbackClubs(interest ClassInterest, clubs []Club) error {
+	if len(clubs) == 0 {
+		return nil
+	}
+	for idx, fallback := range interest.Fallbacks {
+		if fallback.Club == "" {
+			continue
+		}
+		if !slices.ContainsFunc(clubs, func(club Club) bool { return club.Name == fallback.Club }) {
+			return fmt.Errorf("fallback %d: club %q is not one of the configured clubs", idx+1, fallback.Club)
+		}
+	}
+	return nil
+}
+
+func validateGroupInterest(interest ClassInterest, profiles map[string]bool) error {
+	for _, member := range interest.With {
+		if !profiles[member] {
+			return fmt.Errorf("unknown profile %q in with", member)
+		}
+	}
+
+	switch interest.GroupPolicy {
+	case "", groupPolicyAllOrNothing, groupPolicyBestEffort:
+		return nil
+	default:
+		return fmt.Errorf("unknown group_policy %q", interest.GroupPolicy)
+	}
+}
+
+// SelectProfiles returns the profile with the given name, or every profile when name is empty.
+func (c *Config) SelectProfiles(name string) ([]Profile, error) {
+	if name == "" {
+		return c.Profiles, nil
+	}
+
+	for _, profile := range c.Profiles {
+		if profile.Name == name {
+			return []Profile{profile}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown profile %q", name)
+}
@@ -0,0 +1,141 @@
+package worldclass
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+)
+
+// editConfigFile parses the YAML file at path with its comments, applies edit to the syntax tree and writes
+// the result back. The rewritten file must still load; otherwise the original contents are restored.
+func editConfigFile(path string, edit func(file *ast.File) error) error {
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config: %w", err)
+	}
+
+	file, err := parser.ParseBytes(original, parser.ParseComments)
+	if err != nil {
+		return fmt.Errorf("parse config: %w", err)
+	}
+
+	if err := edit(file); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	mode := info.Mode().Perm()
+
+	data := file.String()
+	if !strings.HasSuffix(data, "\n") {
+		data += "\n"
+	}
+	if err := os.WriteFile(path, []byte(data), mode); err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+
+	if _, err := LoadConfigForDiscovery(path); err != nil {
+		if restoreErr := os.WriteFile(path, original, mode); restoreErr != nil {
+			return fmt.Errorf("updated config is invalid (%v) and could not be restored: %w", err, restoreErr)
+		}
+		return fmt.Errorf("updated config is invalid, left unchanged: %w", err)
+	}
+	return nil
+}
+
+// setConfigKey replaces (or appends) a top-level key in the YAML file at path, keeping the rest
+// of the document, including comments, untouched.
+func setConfigKey(path, key string, value any) error {
+	return editConfigFile(path, func(file *ast.File) error {
+		return setNodeKey(file, nil, key, value)
+	})
+}
+
+// setNodeKey replaces key under the mapping at parent, or adds it when the mapping does not have it yet.
+func setNodeKey(file *ast.File, parent []any, key string, value any) error {
+	keyPath := configPath(append(slices.Clone(parent), key)...)
+	if nodeExists(file, keyPath) {
+		node, err := yaml.ValueToNode(value)
+		if err != nil {
+			return fmt.Errorf("encode %s: %w", key, err)
+		}
+		if err := keyPath.ReplaceWithNode(file, node); err != nil {
+			return fmt.Errorf("replace %s: %w", key, err)
+		}
+		return nil
+	}
+
+	node, err := yaml.ValueToNode(yaml.MapSlice{{Key: key, Value: value}})
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", key, err)
+	}
+	// Merging into an empty flow mapping ("interests: {}") keeps the braces around block content,
+	// so such a mapping is replaced outright.
+	if len(parent) > 0 {
+		if existing, err := configPath(parent...).FilterFile(file); err == nil {
+			if mapping, ok := existing.(*ast.MappingNode); ok && mapping.IsFlowStyle && len(mapping.Values) == 0 {
+				return replaceEmptyMapping(file, parent, node)
+			}
+		}
+	}
+	if err := configPath(parent...).MergeFromNode(file, node); err != nil {
+		return fmt.Errorf("add %s: %w", key, err)
+	}
+	return nil
+}
+
+// replaceEmptyMapping swaps the empty flow mapping at path for node, indenting node as a block under its key.
+func replaceEmptyMapping(file *ast.File, path []any, node ast.Node) error {
+	target := configPath(path...)
+	if err := target.ReplaceWithNode(file, node); err != nil {
+		return err
+	}
+
+	key, ok := path[len(path)-1].(string)
+	if !ok {
+		return nil
+	}
+	parent, err := configPath(path[:len(path)-1]...).FilterFile(file)
+	if err != nil {
+		return nil
+	}
+	mapping, ok := parent.(*ast.MappingNode)
+	if !ok {
+		return nil
+	}
+	for _, value := range mapping.Values {
+		if keyText(value.Key) == key {
+			want := value.Key.GetToken().Position.Column + 2
+			value.Value.AddColumn(want - value.Value.GetToken().Position.Column)
+			break
+		}
+	}
+	return nil
+}
+
+// configPath builds a YAML path from mapping keys (strings) and sequence indexes (ints).
+func configPath(segments ...any) *yaml.Path {
+	builder := (&yaml.PathBuilder{}).Root()
+	for _, segment := range segments {
+		switch segment := segment.(type) {
+		case string:
+			builder = builder.Child(segment)
+		case int:
+			builder = builder.Index(uint(segment))
+		}
+	}
+	return builder.Build()
+}
+
+func nodeExists(file *ast.File, path *yaml.Path) bool {
+	node, err := path.FilterFile(file)
+	return err == nil && node != nil
+}
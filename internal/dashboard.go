@@ -0,0 +1,449 @@
+package worldclass
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"slices"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+const defaultDashboardAddress = "127.0.0.1:8080"
+
+// webFiles holds the dashboard page and assets and the HTML report template.
+//
+//go:embed web
+var webFiles embed.FS
+
+// ServeOptions controls the behavior of RunServe.
+type ServeOptions struct {
+	Profile string
+	// Address and Password override the dashboard section of the config when set.
+	Address  string
+	Password string
+	// Loop runs the booking loop in the same process so the dashboard can show its status.
+	Loop   bool
+	Logger *slog.Logger
+}
+
+// RunServe starts the password-protected web dashboard and, with opts.Loop, the booking loop.
+// It returns when the process is interrupted or the server fails.
+func RunServe(cfg *Config, opts ServeOptions) error {
+	if cfg == nil {
+		return fmt.Errorf("configuration is required")
+	}
+
+	profiles, err := cfg.SelectProfiles(opts.Profile)
+	if err != nil {
+		return err
+	}
+
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return fmt.Errorf("load timezone %s: %w", cfg.Timezone, err)
+	}
+
+	address := firstNonEmpty(opts.Address, cfg.Dashboard.Address, defaultDashboardAddress)
+	password := firstNonEmpty(opts.Password, cfg.Dashboard.Password)
+	if password == "" {
+		return errors.New("dashboard.password (or --password) must be set")
+	}
+
+	var hub *sentry.Hub
+	if opts.Loop {
+		hub, err = initSentry(cfg.Sentry)
+		if err != nil {
+			return err
+		}
+		if hub != nil {
+			defer sentry.Flush(5 * time.Second)
+		}
+	}
+
+	logger := loggerOrDefault(opts.Logger)
+	accounts := make([]*account, 0, len(profiles))
+	for _, profile := range profiles {
+		acct, err := newAccount(cfg, profile, hub, logger)
+		if err != nil {
+			return err
+		}
+		if opts.Loop {
+			acct.state = newLoopState(profile.Name)
+		}
+		accounts = append(accounts, acct)
+	}
+
+	csrf := make([]byte, 16)
+	if _, err := rand.Read(csrf); err != nil {
+		return fmt.Errorf("generate form token: %w", err)
+	}
+
+	tmpl, err := template.New("dashboard.html").Funcs(template.FuncMap{
+		"clock": func(t time.Time) string {
+			if t.IsZero() {
+				return "-"
+			}
+			return t.In(location).Format("Mon 02 Jan 15:04")
+		},
+		"actionData": func(page dashboardPage, classInfo dashboardClass, action string) dashboardAction {
+			return dashboardAction{Action: action, CSRF: page.CSRF, Profile: page.Profile, Class: classInfo.Class}
+		},
+	}).ParseFS(webFiles, "web/dashboard.html")
+	if err != nil {
+		return fmt.Errorf("parse dashboard template: %w", err)
+	}
+
+	d := &dashboard{
+		accounts: accounts,
+		location: location,
+		password: password,
+		csrf:     hex.EncodeToString(csrf),
+		loop:     opts.Loop,
+		tmpl:     tmpl,
+		log:      logger,
+		sessions: make(map[string]BookingSession),
+	}
+
+	server := &http.Server{
+		Addr:              address,
+		Handler:           d.routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if opts.Loop {
+		go func() {
+			if err := runAccountLoops(ctx, cfg, accounts, location); err != nil {
+				logger.Error("booking loop stopped", "error", err)
+			}
+		}()
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		logger.Info("dashboard listening", "address", address, "loop", opts.Loop)
+		serveErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	}
+}
+
+type dashboard struct {
+	accounts []*account
+	location *time.Location
+	password string
+	// csrf is embedded in every form and checked on POST, since browsers replay basic auth cross-site.
+	csrf string
+	loop bool
+	tmpl *template.Template
+	log  *slog.Logger
+
+	sessionsMu sync.Mutex
+	sessions   map[string]BookingSession
+}
+
+type dashboardPage struct {
+	Profiles  []string
+	Profile   string
+	CSRF      string
+	Message   string
+	Error     string
+	Loop      bool
+	Status    LoopStatus
+	Bookings  []dashboardClass
+	Clubs     []dashboardClub
+	Interests []dashboardInterest
+	Timezone  string
+}
+
+type dashboardClub struct {
+	Name string
+	Days []dashboardDay
+}
+
+type dashboardDay struct {
+	Label   string
+	Classes []dashboardClass
+}
+
+type dashboardClass struct {
+	Class
+	State    string
+	Start    time.Time
+	Interest bool
+}
+
+// dashboardAction fills the book/cancel form template.
+type dashboardAction struct {
+	Action  string
+	CSRF    string
+	Profile string
+	Class   Class
+}
+
+type dashboardInterest struct {
+	Club  string
+	Day   string
+	Time  string
+	Title string
+}
+
+func (d *dashboard) routes() http.Handler {
+	mux := http.NewServeMux()
+	static, _ := fs.Sub(webFiles, "web")
+	mux.Handle("GET /static/", http.StripPrefix("/static/", http.FileServerFS(static)))
+	mux.HandleFunc("GET /{$}", d.handleIndex)
+	mux.HandleFunc("POST /book", d.handleAction)
+	mux.HandleFunc("POST /cancel", d.handleAction)
+	return d.requireAuth(mux)
+}
+
+// requireAuth accepts any user name with the dashboard password over HTTP basic auth.
+func (d *dashboard) requireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, password, ok := r.BasicAuth()
+		if !ok || subtle.ConstantTimeCompare([]byte(password), []byte(d.password)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="worldclass-scheduler"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (d *dashboard) account(name string) *account {
+	if name == "" && len(d.accounts) > 0 {
+		return d.accounts[0]
+	}
+	for _, acct := range d.accounts {
+		if acct.profile.Name == name {
+			return acct
+		}
+	}
+	return nil
+}
+
+func (d *dashboard) handleIndex(w http.ResponseWriter, r *http.Request) {
+	acct := d.account(r.URL.Query().Get("profile"))
+	if acct == nil {
+		http.Error(w, "unknown profile", http.StatusNotFound)
+		return
+	}
+
+	page := dashboardPage{
+		Profile:  acct.profile.Name,
+		CSRF:     d.csrf,
+		Message:  r.URL.Query().Get("msg"),
+		Error:    r.URL.Query().Get("err"),
+		Loop:     d.loop,
+		Status:   acct.state.snapshot(),
+		Timezone: d.location.String(),
+	}
+	for _, other := range d.accounts {
+		page.Profiles = append(page.Profiles, other.profile.Name)
+	}
+	for _, clubName := range sortedKeys(acct.profile.Interests) {
+		for _, interest := range acct.profile.Interests[clubName] {
+			page.Interests = append(page.Interests, dashboardInterest{
+				Club:  clubName,
+				Day:   interestDay(interest),
+				Time:  interest.Time,
+				Title: interestLabel(interest),
+			})
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	classes, err := acct.fetcher.FetchClasses(ctx, acct.profile.Credentials, acct.displayClubs())
+	if err != nil {
+		page.Error = "fetch failed: " + err.Error()
+	} else {
+		page.Clubs, page.Bookings = d.arrange(acct, classes)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := d.tmpl.Execute(w, page); err != nil {
+		d.log.Error("render dashboard", "error", err)
+	}
+}
+
+// arrange groups classes into per-club weeks in schedule order and collects the booked ones by start time.
+func (d *dashboard) arrange(acct *account, classes []Class) ([]dashboardClub, []dashboardClass) {
+	now := acct.clock.Now().In(d.location)
+
+	var (
+		clubs    []dashboardClub
+		bookings []dashboardClass
+	)
+	for _, club := range acct.profile.Clubs {
+		entry := dashboardClub{Name: club.Name}
+		for _, classInfo := range classes {
+			if classInfo.ClubID != club.ID {
+				continue
+			}
+			start, _ := classDate(classInfo, d.location, now)
+			item := dashboardClass{
+				Class: classInfo,
+				State: classState(classInfo, d.location, now),
+				Start: start,
+			}
+			for _, interest := range acct.profile.Interests[classInfo.ClubName] {
+				if interestMatches(classInfo, interest, now, nil) {
+					item.Interest = true
+					break
+				}
+			}
+
+			if n := len(entry.Days); n == 0 || entry.Days[n-1].Label != classInfo.Day {
+				entry.Days = append(entry.Days, dashboardDay{Label: classInfo.Day})
+			}
+			day := &entry.Days[len(entry.Days)-1]
+			day.Classes = append(day.Classes, item)
+
+			if classInfo.Booked {
+				bookings = append(bookings, item)
+			}
+		}
+		clubs = append(clubs, entry)
+	}
+
+	sort.SliceStable(bookings, func(i, j int) bool {
+		return bookings[i].Start.Before(bookings[j].Start)
+	})
+	return clubs, bookings
+}
+
+func (d *dashboard) handleAction(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(r.PostForm.Get("csrf")), []byte(d.csrf)) != 1 {
+		http.Error(w, "invalid form token", http.StatusForbidden)
+		return
+	}
+
+	acct := d.account(r.PostForm.Get("profile"))
+	if acct == nil {
+		http.Error(w, "unknown profile", http.StatusNotFound)
+		return
+	}
+	clubID, classID := r.PostForm.Get("club_id"), r.PostForm.Get("class_id")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	verb := historyBooked
+	if r.URL.Path == "/cancel" {
+		verb = historyCancelled
+	}
+	// The class is looked up in a fresh schedule rather than trusted from the form, so history records what
+	// the site lists and cancellations follow the scraped cancel link.
+	classInfo, err := d.lookupClass(ctx, acct, clubID, classID)
+	if err == nil {
+		err = d.withSession(ctx, acct, func(session BookingSession) error {
+			if verb == historyCancelled {
+				return session.Cancel(ctx, classInfo.CancelURL)
+			}
+			return session.Book(ctx, classInfo.ClubID, classInfo.ClassID)
+		})
+	}
+	title := firstNonEmpty(classInfo.Title, "class "+classID)
+
+	query := url.Values{"profile": {acct.profile.Name}}
+	if err != nil {
+		acct.log.Warn("dashboard action failed", "class_id", classID, "title", title, "error", err)
+		query.Set("err", fmt.Sprintf("%s: %v", title, err))
+	} else {
+		acct.log.Info("dashboard action", "action", verb, "class_id", classID, "title", title)
+		acct.recordBooking(acct.profile.Name, verb, classInfo, sourceDashboard)
+		query.Set("msg", fmt.Sprintf("%s %s", verb, title))
+	}
+	http.Redirect(w, r, "/?"+query.Encode(), http.StatusSeeOther)
+}
+
+// lookupClass fetches the schedule of one of acct's clubs and returns the class with classID.
+func (d *dashboard) lookupClass(ctx context.Context, acct *account, clubID, classID string) (Class, error) {
+	clubs := slices.DeleteFunc(acct.displayClubs(), func(club Club) bool { return club.ID != clubID })
+	if len(clubs) == 0 || classID == "" {
+		return Class{}, errors.New("unknown class")
+	}
+	classes, err := acct.fetcher.FetchClasses(ctx, acct.profile.Credentials, clubs)
+	if err != nil {
+		return Class{}, fmt.Errorf("fetch schedule: %w", err)
+	}
+	for _, classInfo := range classes {
+		if classInfo.ClubID == clubID && classInfo.ClassID == classID {
+			return classInfo, nil
+		}
+	}
+	return Class{}, errors.New("class is no longer on the schedule")
+}
+
+// withSession runs action with the profile's cached booking session, logging in when there is none and
+// dropping the session when the action fails so the next one starts fresh. The lock only guards the cache:
+// logins and actions of different profiles run in parallel.
+func (d *dashboard) withSession(ctx context.Context, acct *account, action func(BookingSession) error) error {
+	d.sessionsMu.Lock()
+	session, ok := d.sessions[acct.profile.Name]
+	d.sessionsMu.Unlock()
+
+	if !ok {
+		var err error
+		session, err = acct.booker.Login(ctx, acct.profile.Credentials)
+		if err != nil {
+			return fmt.Errorf("login: %w", err)
+		}
+		d.sessionsMu.Lock()
+		d.sessions[acct.profile.Name] = session
+		d.sessionsMu.Unlock()
+	}
+
+	if err := action(session); err != nil {
+		d.sessionsMu.Lock()
+		if d.sessions[acct.profile.Name] == session {
+			delete(d.sessions, acct.profile.Name)
+		}
+		d.sessionsMu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+package worldclass
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDashboardActionUsesScrapedClass(t *testing.T) {
+	classes := []Class{
+		{ClubID: "454", ClubName: "Park Lake", Day: "Luni 19.10", Time: "18:00 - 19:00", Title: "PILATES", ClassID: "1", Bookable: true},
+		{ClubID: "454", ClubName: "Park Lake", Day: "Luni 19.10", Time: "19:00 - 20:00", Title: "BODYPUMP", ClassID: "2", Booked: true, CancelURL: "https://example.com/cancel-reservation.php?res=2"},
+	}
+
+	tests := []struct {
+		name          string
+		path          string
+		form          url.Values
+		wantBooked    []string
+		wantCancelled []string
+		wantQuery     string
+	}{
+		{
+			name:       "book",
+			path:       "/book",
+			form:       url.Values{"club_id": {"454"}, "class_id": {"1"}, "title": {"FORGED"}},
+			wantBooked: []string{"1"},
+			wantQuery:  "msg=booked+PILATES",
+		},
+		{
+			name:          "cancel",
+			path:          "/cancel",
+			form:          url.Values{"club_id": {"454"}, "class_id": {"2"}},
+			wantCancelled: []string{classes[1].CancelURL},
+			wantQuery:     "msg=cancelled+BODYPUMP",
+		},
+		{
+			name:      "class not on the schedule",
+			path:      "/book",
+			form:      url.Values{"club_id": {"454"}, "class_id": {"9"}},
+			wantQuery: "err=",
+		},
+		{
+			name:      "club of another profile",
+			path:      "/book",
+			form:      url.Values{"club_id": {"999"}, "class_id": {"1"}},
+			wantQuery: "err=",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			booker := &fakeBooker{}
+			acct := newTestAccount(&fakeFetcher{classes: classes}, booker)
+			acct.profile.Clubs = []Club{{ID: "454", Name: "Park Lake"}}
+			d := &dashboard{accounts: []*account{acct}, location: time.UTC, csrf: "token", log: acct.log, sessions: make(map[string]BookingSession)}
+
+			form := tt.form
+			form.Set("csrf", "token")
+			form.Set("profile", acct.profile.Name)
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			rec := httptest.NewRecorder()
+			d.handleAction(rec, req)
+
+			if location := rec.Header().Get("Location"); !strings.Contains(location, tt.wantQuery) {
+				t.Errorf("redirected to %q, want %q in the query", location, tt.wantQuery)
+			}
+			if got := booker.bookedIDs(); !slices.Equal(got, tt.wantBooked) {
+				t.Errorf("booked %v, want %v", got, tt.wantBooked)
+			}
+			if !slices.Equal(booker.cancelled, tt.wantCancelled) {
+				t.Errorf("cancelled %v, want %v", booker.cancelled, tt.wantCancelled)
+			}
+		})
+	}
+}
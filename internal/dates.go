@@ -0,0 +1,276 @@
+package worldclass
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	// dateRangeSeparator splits "2026-12-20..2027-01-03" skip ranges.
+	dateRangeSeparator = ".."
+	// maxOccurrenceWeeks bounds the search for an occurrence outside the skipped dates.
+	maxOccurrenceWeeks = 520
+)
+
+// civilDate is a calendar date as yyyymmdd, compared without regard to time zones.
+type civilDate int
+
+func civilDateOf(t time.Time) civilDate {
+	year, month, day := t.Date()
+	return civilDate(year*10000 + int(month)*100 + day)
+}
+
+func parseCivilDate(raw string) (civilDate, error) {
+	t, err := time.Parse(dateLayout, strings.TrimSpace(raw))
+	if err != nil {
+		return 0, fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", raw)
+	}
+	return civilDateOf(t), nil
+}
+
+// dateRange is an inclusive range of calendar dates.
+type dateRange struct {
+	from, until civilDate
+}
+
+func (r dateRange) contains(date civilDate) bool {
+	return date >= r.from && date <= r.until
+}
+
+// parseDateRange reads a single date or a "from..until" range.
+func parseDateRange(raw string) (dateRange, error) {
+	fromText, untilText, isRange := strings.Cut(raw, dateRangeSeparator)
+	from, err := parseCivilDate(fromText)
+	if err != nil {
+		return dateRange{}, err
+	}
+	if !isRange {
+		return dateRange{from: from, until: from}, nil
+	}
+	until, err := parseCivilDate(untilText)
+	if err != nil {
+		return dateRange{}, err
+	}
+	if until < from {
+		return dateRange{}, fmt.Errorf("date range %q ends before it starts", raw)
+	}
+	return dateRange{from: from, until: until}, nil
+}
+
+func parseDateRanges(raw []string) ([]dateRange, error) {
+	ranges := make([]dateRange, 0, len(raw))
+	for _, entry := range raw {
+		r, err := parseDateRange(entry)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+// hasDateBounds reports whether the interest is limited to particular dates at all.
+func (i ClassInterest) hasDateBounds() bool {
+	return i.Date != "" || i.From != "" || i.Until != "" || len(i.Skip) > 0 || len(i.skipRanges) > 0
+}
+
+// activeOn reports whether the interest books classes on day: its date (for one-off interests), its from/until
+// season and its skip dates, including the profile's skip dates and calendars.
+func (i ClassInterest) activeOn(day time.Time) (bool, error) {
+	date := civilDateOf(day)
+
+	if i.Date != "" {
+		only, err := parseCivilDate(i.Date)
+		if err != nil {
+			return false, err
+		}
+		if date != only {
+			return false, nil
+		}
+	}
+	if i.From != "" {
+		from, err := parseCivilDate(i.From)
+		if err != nil {
+			return false, fmt.Errorf("from: %w", err)
+		}
+		if date < from {
+			return false, nil
+		}
+	}
+	if i.Until != "" {
+		until, err := parseCivilDate(i.Until)
+		if err != nil {
+			return false, fmt.Errorf("until: %w", err)
+		}
+		if date > until {
+			return false, nil
+		}
+	}
+
+	skip, err := parseDateRanges(i.Skip)
+	if err != nil {
+		return false, fmt.Errorf("skip: %w", err)
+	}
+	for _, r := range append(skip, i.skipRanges...) {
+		if r.contains(date) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// lastDate returns the last date the interest can occur on, when it has one.
+func (i ClassInterest) lastDate() (civilDate, bool) {
+	if i.Date != "" {
+		date, err := parseCivilDate(i.Date)
+		return date, err == nil
+	}
+	if i.Until != "" {
+		date, err := parseCivilDate(i.Until)
+		return date, err == nil
+	}
+	return 0, false
+}
+
+// nextActiveOccurrence returns the first weekly occurrence at or after start on which the interest is active.
+// It reports false when the interest has no such occurrence, e.g. because its date or season is over.
+func nextActiveOccurrence(interest ClassInterest, start time.Time, minuteOfDay int) (time.Time, bool, error) {
+	last, bounded := interest.lastDate()
+	for week := 0; week < maxOccurrenceWeeks; week++ {
+		if bounded && civilDateOf(start) > last {
+			return time.Time{}, false, nil
+		}
+		active, err := interest.activeOn(start)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		if active {
+			return start, true, nil
+		}
+		start = nextWeek(start, minuteOfDay)
+	}
+	return time.Time{}, false, nil
+}
+
+// nextWeek moves start seven days ahead, keeping the wall clock time across DST changes.
+func nextWeek(start time.Time, minuteOfDay int) time.Time {
+	next := start.AddDate(0, 0, 7)
+	return time.Date(next.Year(), next.Month(), next.Day(), minuteOfDay/60, minuteOfDay%60, 0, 0, start.Location())
+}
+
+// dueWithin reports whether the interest has an active weekday in [from, until). Interests whose dates cannot
+// be parsed count as due, so their errors surface where they are booked.
+func (i ClassInterest) dueWithin(from, until time.Time) bool {
+	if !i.hasDateBounds() {
+		return true
+	}
+	weekdays, err := interestWeekdays(i)
+	if err != nil {
+		return true
+	}
+	for day := from; day.Before(until); day = day.AddDate(0, 0, 1) {
+		if !containsWeekday(weekdays, day.Weekday()) {
+			continue
+		}
+		if active, err := i.activeOn(day); err != nil || active {
+			return true
+		}
+	}
+	return false
+}
+
+func containsWeekday(weekdays []time.Weekday, weekday time.Weekday) bool {
+	for _, candidate := range weekdays {
+		if candidate == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// classOnActiveDate checks a scraped class against the interest's dates. Day labels carry the date ("Sâmbătă
+// 25.10", its year taken as the one nearest reference); labels without one only allow the weekday of a one-off
+// interest's date.
+func classOnActiveDate(classInfo Class, interest ClassInterest, reference time.Time) bool {
+	if !interest.hasDateBounds() {
+		return true
+	}
+
+	if day, month, ok := labelDayMonth(classInfo.Day); ok {
+		active, err := interest.activeOn(nearestYearDate(reference, month, day, 0, 0))
+		return err == nil && active
+	}
+
+	if interest.Date == "" {
+		return true
+	}
+	date, err := time.Parse(dateLayout, interest.Date)
+	if err != nil {
+		return false
+	}
+	weekday, ok := dayLabelWeekday(classInfo.Day)
+	return ok && weekday == date.Weekday()
+}
+
+// classDate returns when classInfo starts, using the date in its day label ("Luni 20.10") so a class is never
+// moved to another week. Labels without a date resolve to the weekday's next occurrence from reference's day.
+func classDate(classInfo Class, location *time.Location, reference time.Time) (time.Time, bool) {
+	hour, minute, err := parseStartTime(classInfo.Time)
+	if err != nil {
+		return time.Time{}, false
+	}
+	reference = reference.In(location)
+
+	if day, month, ok := labelDayMonth(classInfo.Day); ok {
+		return nearestYearDate(reference, month, day, hour, minute), true
+	}
+
+	weekday, ok := dayLabelWeekday(classInfo.Day)
+	if !ok {
+		return time.Time{}, false
+	}
+	offset := (int(weekday) - int(reference.Weekday()) + 7) % 7
+	day := reference.AddDate(0, 0, offset)
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, location), true
+}
+
+// nearestYearDate completes a day and month without a year with the year that puts it closest to reference.
+func nearestYearDate(reference time.Time, month, day, hour, minute int) time.Time {
+	start := time.Date(reference.Year(), time.Month(month), day, hour, minute, 0, 0, reference.Location())
+	const halfYear = 183 * 24 * time.Hour
+	switch {
+	case start.Sub(reference) > halfYear:
+		start = start.AddDate(-1, 0, 0)
+	case reference.Sub(start) > halfYear:
+		start = start.AddDate(1, 0, 0)
+	}
+	return start
+}
+
+// labelDate resolves a day label with a date ("Luni 20.10") to midnight of that date, in the year closest to
+// reference.
+func labelDate(label string, reference time.Time) (time.Time, bool) {
+	day, month, ok := labelDayMonth(label)
+	if !ok {
+		return time.Time{}, false
+	}
+	return nearestYearDate(reference, month, day, 0, 0), true
+}
+
+// labelDayMonth extracts the day and month from a scraped day label such as "Luni 20.10".
+func labelDayMonth(label string) (int, int, bool) {
+	fields := strings.Fields(label)
+	if len(fields) < 2 {
+		return 0, 0, false
+	}
+	dayText, monthText, ok := strings.Cut(strings.TrimSuffix(fields[1], "."), ".")
+	day, dayErr := strconv.Atoi(dayText)
+	month, monthErr := strconv.Atoi(monthText)
+	if !ok || dayErr != nil || monthErr != nil || day < 1 || day > 31 || month < 1 || month > 12 {
+		return 0, 0, false
+	}
+	return day, month, true
+}
@@ -0,0 +1,98 @@
+package worldclass
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDateRange(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    dateRange
+		wantErr bool
+	}{
+		{raw: "2026-12-24", want: dateRange{from: 20261224, until: 20261224}},
+		{raw: "2026-12-20..2027-01-03", want: dateRange{from: 20261220, until: 20270103}},
+		{raw: "2027-01-03..2026-12-20", wantErr: true},
+		{raw: "24.12.2026", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := parseDateRange(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDateRange(%q) error = %v, want error %v", tt.raw, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseDateRange(%q) = %v, want %v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterestActiveOn(t *testing.T) {
+	season := ClassInterest{From: "2026-10-01", Until: "2027-03-31", Skip: []string{"2026-12-20..2027-01-03"}}
+	oneOff := ClassInterest{Date: "2026-10-24"}
+
+	tests := []struct {
+		name     string
+		interest ClassInterest
+		day      string
+		want     bool
+	}{
+		{name: "inside the season", interest: season, day: "2026-11-02", want: true},
+		{name: "before the season", interest: season, day: "2026-09-28"},
+		{name: "after the season", interest: season, day: "2027-04-05"},
+		{name: "first skipped day", interest: season, day: "2026-12-20"},
+		{name: "last skipped day", interest: season, day: "2027-01-03"},
+		{name: "one-off date", interest: oneOff, day: "2026-10-24", want: true},
+		{name: "a week after the one-off date", interest: oneOff, day: "2026-10-31"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			day, err := time.Parse(dateLayout, tt.day)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := tt.interest.activeOn(day)
+			if err != nil {
+				t.Fatalf("activeOn: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("activeOn(%s) = %v, want %v", tt.day, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassOnActiveDate(t *testing.T) {
+	interest := ClassInterest{Until: "2027-01-03", Skip: []string{"2026-12-24"}}
+	oneOff := ClassInterest{Date: "2026-10-24"}
+	// Mid-December: "02.01" is next January, not the past one.
+	reference := time.Date(2026, 12, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		interest ClassInterest
+		day      string
+		want     bool
+	}{
+		{name: "date label inside the range", interest: interest, day: "Vineri 18.12", want: true},
+		{name: "skipped date", interest: interest, day: "Joi 24.12"},
+		{name: "next year before until", interest: interest, day: "Sâmbătă 02.01", want: true},
+		{name: "next year after until", interest: interest, day: "Luni 04.01"},
+		{name: "weekday label of a one-off date", interest: oneOff, day: "Sâmbătă", want: true},
+		{name: "other weekday label of a one-off date", interest: oneOff, day: "Duminică"},
+		{name: "no date bounds", interest: ClassInterest{}, day: "Luni 04.01", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			classInfo := Class{Day: tt.day, Time: "18:00 - 19:00", Title: "PILATES"}
+			if got := classOnActiveDate(classInfo, tt.interest, reference); got != tt.want {
+				t.Errorf("classOnActiveDate(%q) = %v, want %v", tt.day, got, tt.want)
+			}
+		})
+	}
+}
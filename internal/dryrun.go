@@ -0,0 +1,130 @@
+package worldclass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+const defaultTimelineWeeks = 2
+
+// occurrence is one future class start of an interest.
+type occurrence struct {
+	handle *scheduledInterest
+	start  time.Time
+}
+
+// upcomingOccurrences lists every interest occurrence starting in [from, until), ordered by start time.
+func upcomingOccurrences(interests map[string][]ClassInterest, loc *time.Location, from, until time.Time) ([]occurrence, error) {
+	var occurrences []occurrence
+	for _, clubName := range sortedKeys(interests) {
+		for _, interest := range interests[clubName] {
+			weekdays, err := interestWeekdays(interest)
+			if err != nil {
+				return nil, fmt.Errorf("parse weekday for %s (%s): %w", clubName, interest.Title, err)
+			}
+
+			earliest, latest, err := interestStartWindow(interest)
+			if err != nil {
+				return nil, fmt.Errorf("parse time for %s (%s): %w", clubName, interest.Title, err)
+			}
+
+			for _, weekday := range weekdays {
+				start := computeNextOccurrence(from, loc, weekday, earliest/60, earliest%60)
+				for start.Before(until) {
+					active, ok, err := nextActiveOccurrence(interest, start, earliest)
+					if err != nil {
+						return nil, fmt.Errorf("dates for %s (%s): %w", clubName, interest.Title, err)
+					}
+					if !ok || !active.Before(until) {
+						break
+					}
+					occurrences = append(occurrences, occurrence{
+						handle: newScheduledInterest(clubName, interest, weekday, len(weekdays) > 1, active, latest-earliest),
+						start:  active,
+					})
+					start = nextWeek(active, earliest)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(occurrences, func(i, j int) bool {
+		return occurrences[i].start.Before(occurrences[j].start)
+	})
+
+	return occurrences, nil
+}
+
+// runScheduleDryRun fetches the schedule and reports what a booking run would do for every interest.
+func runScheduleDryRun(cfg *Config, profiles []Profile, logger *slog.Logger) error {
+	var errs []error
+	for _, profile := range profiles {
+		acct, err := newAccount(cfg, profile, nil, logger)
+		if err != nil {
+			return err
+		}
+		acct.dryRun = true
+		acct.notifier = nil
+
+		// A dry run leaves no trace in the state directory, snapshots included.
+		ctx, cancel := context.WithTimeout(withoutSnapshot(context.Background()), 30*time.Second)
+		results, err := scheduleInterests(ctx, acct, profile.Interests, time.Time{})
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("profile %s: %w", profile.Name, err))
+			continue
+		}
+
+		for _, res := range results {
+			attrs := append(interestAttrs(res.ClubName, res.Interest), "action", res.Status.String())
+			if res.Reason != "" {
+				attrs = append(attrs, "reason", res.Reason)
+			}
+			acct.log.Info("dry run", attrs...)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// runScheduleTimeline prints when the loop would wake up for each interest over the next weeks.
+func runScheduleTimeline(cfg *Config, profiles []Profile, logger *slog.Logger, weeks int) error {
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return fmt.Errorf("load timezone %s: %w", cfg.Timezone, err)
+	}
+	if weeks <= 0 {
+		weeks = defaultTimelineWeeks
+	}
+
+	logger = loggerOrDefault(logger)
+	now := time.Now().In(location)
+	until := now.AddDate(0, 0, 7*weeks)
+
+	for _, profile := range profiles {
+		profileLog := logger.With("profile", profile.Name)
+		occurrences, err := upcomingOccurrences(profile.Interests, location, now, until)
+		if err != nil {
+			return fmt.Errorf("profile %s: %w", profile.Name, err)
+		}
+		if len(occurrences) == 0 {
+			profileLog.Info("dry run: no upcoming interests", "weeks", weeks)
+			continue
+		}
+
+		for _, occ := range occurrences {
+			profileLog.Info("dry run: planned window", append(interestAttrs(occ.handle.Club, occ.handle.Interest),
+				"start", occ.start.Format(time.RFC1123),
+				"wake", WakeTime(occ.start).Format(time.RFC1123),
+				"window_opens", occ.start.Add(-bookingLeadTime).Format(time.RFC1123),
+				"cutoff", occ.handle.LastStart.Add(bookingGracePeriod).Format(time.RFC1123),
+			)...)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package worldclass
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// fakeClock is a Clock whose Sleep advances Now instantly and records each sleep.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	c.sleeps = append(c.sleeps, d)
+	return nil
+}
+
+// fakeFetcher serves a fixed schedule, or successive schedules with the last one repeating, and counts the
+// fetches.
+type fakeFetcher struct {
+	mu        sync.Mutex
+	classes   []Class
+	schedules [][]Class
+	err       error
+	calls     int
+}
+
+func (f *fakeFetcher) FetchClasses(ctx context.Context, creds Credentials, clubs []Club) ([]Class, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	if len(f.schedules) > 0 {
+		return append([]Class(nil), f.schedules[min(f.calls, len(f.schedules))-1]...), nil
+	}
+	return append([]Class(nil), f.classes...), nil
+}
+
+// fakeBooker records the classes booked and cancelled through its sessions. Book fails with bookErr[classID]
+// and Login with loginErr[email] when set.
+type fakeBooker struct {
+	mu        sync.Mutex
+	bookErr   map[string]error
+	loginErr  map[string]error
+	booked    []string
+	cancelled []string
+}
+
+func (b *fakeBooker) Login(ctx context.Context, creds Credentials) (BookingSession, error) {
+	if err := b.loginErr[creds.Email]; err != nil {
+		return nil, err
+	}
+	return fakeSession{booker: b}, nil
+}
+
+func (b *fakeBooker) bookedIDs() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.booked...)
+}
+
+type fakeSession struct {
+	booker *fakeBooker
+}
+
+func (s fakeSession) Book(ctx context.Context, clubID, classID string) error {
+	s.booker.mu.Lock()
+	defer s.booker.mu.Unlock()
+	if err := s.booker.bookErr[classID]; err != nil {
+		return err
+	}
+	s.booker.booked = append(s.booker.booked, classID)
+	return nil
+}
+
+func (s fakeSession) Cancel(ctx context.Context, cancelURL string) error {
+	s.booker.mu.Lock()
+	defer s.booker.mu.Unlock()
+	s.booker.cancelled = append(s.booker.cancelled, cancelURL)
+	return nil
+}
+
+// newTestAccount builds an account without a state directory or notifiers around the given fakes.
+func newTestAccount(fetcher Fetcher, booker Booker) *account {
+	return &account{
+		profile:  Profile{Name: "test"},
+		fetcher:  fetcher,
+		booker:   booker,
+		clock:    realClock{},
+		log:      discardLogger(),
+		location: time.UTC,
+	}
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// recordingNotifier keeps every notification it is given.
+type recordingNotifier struct {
+	mu  sync.Mutex
+	got []Notification
+}
+
+func (r *recordingNotifier) Notify(ctx context.Context, n Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.got = append(r.got, n)
+	return nil
+}
+
+func (r *recordingNotifier) events() []EventKind {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events := make([]EventKind, 0, len(r.got))
+	for _, n := range r.got {
+		events = append(events, n.Event)
+	}
+	return events
+}
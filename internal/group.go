@@ -0,0 +1,150 @@
+package worldclass
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// groupRollbackTimeout bounds cancelling the bookings of a failed all-or-nothing attempt.
+const groupRollbackTimeout = time.Minute
+
+// groupMemberResult records the outcome of booking one member of a group interest.
+type groupMemberResult struct {
+	profile Profile
+	session BookingSession
+	// preexisting marks members that already held the booking, so a rollback leaves them untouched.
+	preexisting bool
+	booked      bool
+	err         error
+}
+
+// bookGroup books classInfo for the interest owner and every profile listed in interest.With concurrently.
+// With the all-or-nothing policy, any failure cancels the bookings made during this attempt and blocks the
+// window, since retrying would book and cancel the owner's seat every few seconds until the cutoff. With
+// best effort, a partial success is retried: members who hold the class count as already booked, so later
+// attempts only book the ones still missing. The returned reason explains a statusBlocked.
+func bookGroup(ctx context.Context, acct *account, classInfo Class, interest ClassInterest) (interestStatus, string) {
+	members := []Profile{acct.profile}
+	for _, name := range interest.With {
+		if name == acct.profile.Name {
+			continue
+		}
+		peer, ok := acct.peers[name]
+		if !ok {
+			acct.log.Error("group booking references unknown profile", append(classAttrs(classInfo), "member", name)...)
+			return statusBlocked, fmt.Sprintf("group member %s is not a configured profile", name)
+		}
+		members = append(members, peer)
+	}
+
+	acct.log.Info("group scheduling attempt", append(classAttrs(classInfo), "phase", "book", "members", len(members))...)
+
+	outcomes := make([]groupMemberResult, len(members))
+	var wg sync.WaitGroup
+	for i, member := range members {
+		wg.Add(1)
+		go func(i int, member Profile) {
+			defer wg.Done()
+			outcomes[i] = bookGroupMember(ctx, acct, member, classInfo, i == 0)
+		}(i, member)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, outcome := range outcomes {
+		switch {
+		case outcome.err != nil:
+			failed++
+			acct.log.Error("group member failed booking", append(classAttrs(classInfo), "member", outcome.profile.Name, "error", outcome.err)...)
+		case outcome.preexisting:
+			acct.log.Info("group member already booked", append(classAttrs(classInfo), "member", outcome.profile.Name)...)
+		case outcome.booked:
+			acct.log.Info("group member booked", append(classAttrs(classInfo), "member", outcome.profile.Name)...)
+			acct.recordBooking(outcome.profile.Name, historyBooked, classInfo, sourceLoop)
+		}
+	}
+
+	if failed == 0 {
+		acct.log.Info("group booked successfully", append(classAttrs(classInfo), "phase", "book")...)
+		reportLoopSuccess(acct.hub, classInfo)
+		acct.notify(EventBooked, classInfo, "group of %d booked successfully", len(members))
+		return statusBooked, ""
+	}
+
+	acct.notifyFailure(classInfo, "group booking failed for %d of %d members", failed, len(members))
+
+	if interest.GroupPolicy == groupPolicyBestEffort {
+		acct.log.Warn("group booking partially succeeded", append(classAttrs(classInfo), "failed", failed, "members", len(members))...)
+		return statusBookingFailed, ""
+	}
+
+	acct.log.Warn("group booking incomplete, cancelling reservations made in this attempt", append(classAttrs(classInfo), "phase", "group_rollback", "failed", failed, "members", len(members))...)
+	// The attempt's context may be nearly spent by now, so the rollback gets its own.
+	rollbackCtx, cancel := context.WithTimeout(context.Background(), groupRollbackTimeout)
+	defer cancel()
+	for _, outcome := range outcomes {
+		if !outcome.booked || outcome.preexisting {
+			continue
+		}
+		link, err := fetchCancelLink(rollbackCtx, acct.fetcher, outcome.profile.Credentials, classInfo)
+		if err == nil {
+			err = outcome.session.Cancel(rollbackCtx, link)
+		}
+		if err != nil {
+			acct.log.Error("failed cancelling group booking", append(classAttrs(classInfo), "phase", "group_rollback", "member", outcome.profile.Name, "error", err)...)
+			reportLoopError(acct.hub, fmt.Errorf("cancel group booking for %s: %w", outcome.profile.Name, err), map[string]string{
+				"phase": "group_rollback",
+				"club":  classInfo.ClubName,
+				"title": classInfo.Title,
+			})
+			continue
+		}
+		acct.log.Info("cancelled group booking", append(classAttrs(classInfo), "phase", "group_rollback", "member", outcome.profile.Name)...)
+		acct.recordBooking(outcome.profile.Name, historyCancelled, classInfo, sourceLoop)
+	}
+
+	return statusBlocked, fmt.Sprintf("group booking failed for %d of %d members and was rolled back", failed, len(members))
+}
+
+// bookGroupMember checks whether member already holds the class and books it otherwise.
+func bookGroupMember(ctx context.Context, acct *account, member Profile, classInfo Class, owner bool) groupMemberResult {
+	outcome := groupMemberResult{profile: member}
+
+	alreadyBooked := owner && classInfo.Booked
+	if !owner {
+		classes, err := acct.fetcher.FetchClasses(ctx, member.Credentials, []Club{{ID: classInfo.ClubID, Name: classInfo.ClubName}})
+		if err != nil {
+			outcome.err = fmt.Errorf("fetch classes: %w", err)
+			return outcome
+		}
+		for _, candidate := range classes {
+			if candidate.ClassID == classInfo.ClassID {
+				alreadyBooked = candidate.Booked
+				break
+			}
+		}
+	}
+
+	if alreadyBooked {
+		outcome.preexisting = true
+		outcome.booked = true
+		return outcome
+	}
+
+	session, err := acct.booker.Login(ctx, member.Credentials)
+	if err != nil {
+		outcome.err = fmt.Errorf("start booking session: %w", err)
+		return outcome
+	}
+	outcome.session = session
+
+	if err := session.Book(ctx, classInfo.ClubID, classInfo.ClassID); err != nil {
+		outcome.err = err
+		return outcome
+	}
+
+	outcome.booked = true
+	return outcome
+}
@@ -0,0 +1,73 @@
+package worldclass
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestBookGroup(t *testing.T) {
+	pilates := Class{ClubID: "454", ClubName: "Park Lake", Day: "Luni 19.10", Time: "18:00 - 19:00", Title: "PILATES", ClassID: "1", Bookable: true, CancelURL: "cancel/1"}
+	ana := Profile{Name: "ana", Credentials: Credentials{Email: "ana@example.com"}}
+	dan := Profile{Name: "dan", Credentials: Credentials{Email: "dan@example.com"}}
+
+	tests := []struct {
+		name          string
+		interest      ClassInterest
+		loginErr      map[string]error
+		wantStatus    interestStatus
+		wantReason    bool
+		wantBooked    []string
+		wantCancelled []string
+	}{
+		{
+			name:       "every member booked",
+			interest:   ClassInterest{Title: "PILATES", With: []string{"dan"}},
+			wantStatus: statusBooked,
+			wantBooked: []string{"1", "1"},
+		},
+		{
+			name:          "all-or-nothing rolls back and settles the window",
+			interest:      ClassInterest{Title: "PILATES", With: []string{"dan"}},
+			loginErr:      map[string]error{dan.Credentials.Email: errors.New("bad password")},
+			wantStatus:    statusBlocked,
+			wantReason:    true,
+			wantBooked:    []string{"1"},
+			wantCancelled: []string{"cancel/1"},
+		},
+		{
+			name:       "best effort keeps the seats and retries",
+			interest:   ClassInterest{Title: "PILATES", With: []string{"dan"}, GroupPolicy: groupPolicyBestEffort},
+			loginErr:   map[string]error{dan.Credentials.Email: errors.New("bad password")},
+			wantStatus: statusBookingFailed,
+			wantBooked: []string{"1"},
+		},
+		{
+			name:       "unknown member",
+			interest:   ClassInterest{Title: "PILATES", With: []string{"eve"}},
+			wantStatus: statusBlocked,
+			wantReason: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			booker := &fakeBooker{loginErr: tt.loginErr}
+			acct := newTestAccount(&fakeFetcher{classes: []Class{pilates}}, booker)
+			acct.profile = ana
+			acct.peers = map[string]Profile{ana.Name: ana, dan.Name: dan}
+
+			status, reason := bookGroup(context.Background(), acct, pilates, tt.interest)
+			if status != tt.wantStatus || (reason != "") != tt.wantReason {
+				t.Errorf("bookGroup() = %v, %q; want %v with reason %v", status, reason, tt.wantStatus, tt.wantReason)
+			}
+			if got := booker.bookedIDs(); !slices.Equal(got, tt.wantBooked) {
+				t.Errorf("booked %v, want %v", got, tt.wantBooked)
+			}
+			if !slices.Equal(booker.cancelled, tt.wantCancelled) {
+				t.Errorf("cancelled %v, want %v", booker.cancelled, tt.wantCancelled)
+			}
+		})
+	}
+}
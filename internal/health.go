@@ -0,0 +1,304 @@
+package worldclass
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	healthFile = "health.json"
+
+	defaultHealthAlertAfter = 2
+	// healthCandidates is how many nearest classes of each kind are suggested for an interest without matches.
+	healthCandidates = 3
+)
+
+// healthMu serialises the read-modify-write of the health file shared by every profile's loop.
+var healthMu sync.Mutex
+
+// HealthConfig controls the weekly interest health check of the booking loop.
+type HealthConfig struct {
+	// AlertAfter is how many consecutive weeks an interest may match no class before an alert is raised;
+	// defaults to 2.
+	AlertAfter int `yaml:"alert_after"`
+}
+
+func (h HealthConfig) validate() error {
+	if h.AlertAfter < 0 {
+		return fmt.Errorf("alert_after must not be negative")
+	}
+	return nil
+}
+
+func (h HealthConfig) alertAfter() int {
+	if h.AlertAfter == 0 {
+		return defaultHealthAlertAfter
+	}
+	return h.AlertAfter
+}
+
+// interestHealth is how an interest fared against one fetched week.
+type interestHealth struct {
+	Club     string
+	Interest ClassInterest
+	Matches  int
+	// SameTitle and SameTime are the nearest candidates when nothing matched: classes with the interest's title
+	// at another time, and classes in the interest's slot with another title.
+	SameTitle []Class
+	SameTime  []Class
+}
+
+// healthEntry is the persisted miss streak of one interest.
+type healthEntry struct {
+	Misses int `json:"misses"`
+	// Week is the last ISO week counted, so each week is counted once however often the loop checks.
+	Week    string `json:"week"`
+	Alerted bool   `json:"alerted,omitempty"`
+}
+
+// checkInterests matches every interest against the week of classes fetched at now. Clubs without any class in
+// the week are skipped, since an empty schedule says more about the fetch than about the interest, and so are
+// interests with no active date in the week.
+func checkInterests(interests map[string][]ClassInterest, classes []Class, now time.Time) []interestHealth {
+	weekStart := periodStart(PeriodWeek, now)
+	var report []interestHealth
+	for _, clubName := range sortedKeys(interests) {
+		var clubClasses []Class
+		for _, classInfo := range classes {
+			if classInfo.ClubName == clubName {
+				clubClasses = append(clubClasses, classInfo)
+			}
+		}
+		if len(clubClasses) == 0 {
+			continue
+		}
+
+		for _, interest := range interests[clubName] {
+			if !interest.dueWithin(weekStart, weekStart.AddDate(0, 0, 7)) {
+				continue
+			}
+			health := interestHealth{Club: clubName, Interest: interest}
+			for _, classInfo := range clubClasses {
+				if interestMatches(classInfo, interest, now, nil) {
+					health.Matches++
+				}
+			}
+			if health.Matches == 0 {
+				health.SameTitle, health.SameTime = nearestCandidates(clubClasses, interest, now)
+			}
+			report = append(report, health)
+		}
+	}
+	return report
+}
+
+// nearestCandidates relaxes interest once on its slot (day and time) and once on its title.
+func nearestCandidates(classes []Class, interest ClassInterest, reference time.Time) (sameTitle, sameTime []Class) {
+	if interest.Title != "" || interest.TitleRegex != "" {
+		titleOnly := ClassInterest{Title: interest.Title, TitleRegex: interest.TitleRegex, Category: interest.Category}
+		sameTitle = matchingClasses(classes, titleOnly, reference)
+	}
+	if interest.Time != "" || interest.StartAfter != "" || interest.StartBefore != "" {
+		slotOnly := ClassInterest{
+			Day:         interest.Day,
+			DayEnglish:  interest.DayEnglish,
+			Days:        interest.Days,
+			Time:        interest.Time,
+			StartAfter:  interest.StartAfter,
+			StartBefore: interest.StartBefore,
+			Category:    interest.Category,
+		}
+		sameTime = matchingClasses(classes, slotOnly, reference)
+	}
+	return sameTitle, sameTime
+}
+
+func matchingClasses(classes []Class, interest ClassInterest, reference time.Time) []Class {
+	var matches []Class
+	for _, classInfo := range classes {
+		if len(matches) == healthCandidates {
+			break
+		}
+		if interestMatches(classInfo, interest, reference, nil) {
+			matches = append(matches, classInfo)
+		}
+	}
+	return matches
+}
+
+// candidateSummary renders candidates as "TITLE Day Time" entries separated by semicolons.
+func candidateSummary(candidates []Class) string {
+	parts := make([]string, 0, len(candidates))
+	for _, classInfo := range candidates {
+		parts = append(parts, fmt.Sprintf("%s %s %s", classInfo.Title, classInfo.Day, classInfo.Time))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func (h interestHealth) nearest() string {
+	var parts []string
+	if len(h.SameTitle) > 0 {
+		parts = append(parts, "same title: "+candidateSummary(h.SameTitle))
+	}
+	if len(h.SameTime) > 0 {
+		parts = append(parts, "same time: "+candidateSummary(h.SameTime))
+	}
+	if len(parts) == 0 {
+		return "no similar classes"
+	}
+	return strings.Join(parts, " | ")
+}
+
+func healthKey(profile, club string, interest ClassInterest) string {
+	return profile + "\t" + club + "\t" + interestKey(interest)
+}
+
+func isoWeek(t time.Time) string {
+	year, week := t.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+// checkHealth matches the interests against the current week once per week, logs the ones without matches
+// with their nearest candidates and raises an alert when an interest reaches the configured number of
+// consecutive weeks without a match.
+func (s *Scheduler) checkHealth(now time.Time) {
+	acct := s.acct
+	week := isoWeek(now)
+	if s.healthWeek == week {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	classes, err := acct.fetcher.FetchClasses(ctx, acct.profile.Credentials, acct.scheduleClubs())
+	cancel()
+	if err != nil {
+		acct.log.Warn("interest health check failed", "error", err)
+		return
+	}
+	s.healthWeek = week
+
+	healthMu.Lock()
+	defer healthMu.Unlock()
+
+	entries := make(map[string]*healthEntry)
+	if err := acct.store.load(healthFile, &entries); err != nil {
+		acct.log.Warn("could not read interest health", "error", err)
+		return
+	}
+
+	for _, health := range checkInterests(acct.profile.Interests, classes, now.In(s.location)) {
+		key := healthKey(acct.profile.Name, health.Club, health.Interest)
+		entry, ok := entries[key]
+		if !ok {
+			entry = &healthEntry{}
+			entries[key] = entry
+		}
+		if entry.Week == week {
+			continue
+		}
+		entry.Week = week
+
+		attrs := interestAttrs(health.Club, health.Interest)
+		if health.Matches > 0 {
+			if entry.Misses > 0 {
+				acct.log.Info("interest matches classes again", append(attrs, "matches", health.Matches)...)
+			}
+			*entry = healthEntry{Week: week}
+			continue
+		}
+
+		entry.Misses++
+		acct.log.Warn("interest matched no class this week", append(attrs, "weeks", entry.Misses, "nearest", health.nearest())...)
+		if entry.Misses < acct.health.alertAfter() || entry.Alerted {
+			continue
+		}
+
+		entry.Alerted = true
+		message := fmt.Sprintf("interest %s (%s) matched no class for %d weeks; nearest: %s", interestLabel(health.Interest), health.Interest.describe(), entry.Misses, health.nearest())
+		acct.notify(EventInterestUnmatched, Class{
+			ClubName: health.Club,
+			Day:      interestDay(health.Interest),
+			Time:     health.Interest.Time,
+			Title:    interestLabel(health.Interest),
+		}, "%s", message)
+		reportLoopError(acct.hub, fmt.Errorf("%s at %s: %s", interestLabel(health.Interest), health.Club, message), map[string]string{
+			"phase": "interest_health",
+			"club":  health.Club,
+			"title": health.Interest.Title,
+		})
+	}
+
+	// Drop the streaks of interests this profile no longer has.
+	for key := range entries {
+		profile, rest, _ := strings.Cut(key, "\t")
+		club, _, _ := strings.Cut(rest, "\t")
+		if profile == acct.profile.Name && !slices.ContainsFunc(acct.profile.Interests[club], func(interest ClassInterest) bool {
+			return healthKey(profile, club, interest) == key
+		}) {
+			delete(entries, key)
+		}
+	}
+
+	if err := acct.store.save(healthFile, entries); err != nil {
+		acct.log.Warn("could not save interest health", "error", err)
+	}
+}
+
+// HealthOptions controls the behavior of RunHealth.
+type HealthOptions struct {
+	Profile string
+	Out     io.Writer
+}
+
+// RunHealth fetches the current week and lists, per interest, how many classes it matches, how many weeks in a
+// row the loop found none, and the nearest candidates for interests without matches.
+func RunHealth(cfg *Config, opts HealthOptions) error {
+	if cfg == nil {
+		return fmt.Errorf("configuration is required")
+	}
+
+	profiles, err := cfg.SelectProfiles(opts.Profile)
+	if err != nil {
+		return err
+	}
+
+	entries := make(map[string]*healthEntry)
+	if err := newStateStore(cfg.StateDir).load(healthFile, &entries); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(outputOrStdout(opts.Out), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROFILE\tCLUB\tINTEREST\tMATCHES\tWEEKS MISSED\tNEAREST")
+	for _, profile := range profiles {
+		acct, err := newAccount(cfg, profile, nil, nil)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		classes, err := acct.fetcher.FetchClasses(ctx, profile.Credentials, acct.scheduleClubs())
+		cancel()
+		if err != nil {
+			return fmt.Errorf("profile %s: %w", profile.Name, err)
+		}
+
+		for _, health := range checkInterests(profile.Interests, classes, acct.clock.Now().In(acct.location)) {
+			missed := 0
+			if entry, ok := entries[healthKey(profile.Name, health.Club, health.Interest)]; ok {
+				missed = entry.Misses
+			}
+			nearest := "-"
+			if health.Matches == 0 {
+				nearest = health.nearest()
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s (%s)\t%d\t%d\t%s\n", profile.Name, health.Club, interestLabel(health.Interest), health.Interest.describe(), health.Matches, missed, nearest)
+		}
+	}
+	return w.Flush()
+}
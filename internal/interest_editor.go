@@ -0,0 +1,391 @@
+package worldclass
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+
+	wc "github.com/tatulea/worldclass-scheduler/pkg/worldclass"
+)
+
+// InterestsOptions controls the behavior of the interests subcommands.
+type InterestsOptions struct {
+	Profile    string
+	ConfigPath string
+	// ClassID picks the scraped class to add; when empty the classes are listed and one is chosen interactively.
+	ClassID string
+	// Club and Index (1-based, as printed by RunInterestsList) pick the interest to remove.
+	Club  string
+	Index int
+	In    io.Reader
+	Out   io.Writer
+	// Logger receives the scraper's diagnostics.
+	Logger *slog.Logger
+}
+
+// RunInterestsList prints the configured interests with the per-club numbers used by RunInterestsRemove.
+func RunInterestsList(cfg *Config, opts InterestsOptions) error {
+	if cfg == nil {
+		return fmt.Errorf("configuration is required")
+	}
+
+	profiles, err := cfg.SelectProfiles(opts.Profile)
+	if err != nil {
+		return err
+	}
+
+	out := outputOrStdout(opts.Out)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROFILE\tCLUB\t#\tDAY\tTIME\tTITLE")
+	count := 0
+	for _, profile := range profiles {
+		for _, clubName := range sortedKeys(profile.Interests) {
+			for idx, interest := range profile.Interests[clubName] {
+				fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n", profile.Name, clubName, idx+1, interestDay(interest), interest.Time, interestLabel(interest))
+				count++
+			}
+		}
+	}
+	if count == 0 {
+		_, err := fmt.Fprintln(out, "no interests configured")
+		return err
+	}
+	return w.Flush()
+}
+
+// RunInterestsAdd scrapes the profile's clubs, lets the user pick a class and appends an interest derived
+// from it to the config file.
+func RunInterestsAdd(cfg *Config, opts InterestsOptions) error {
+	profileIdx, profile, err := editableProfile(cfg, opts.Profile)
+	if err != nil {
+		return err
+	}
+
+	client, err := wc.NewClient(cfg.BaseURL, loggerOrDefault(opts.Logger))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	classes, err := client.FetchClasses(ctx, profile.Credentials, profile.Clubs)
+	if err != nil {
+		return fmt.Errorf("profile %s: %w", profile.Name, err)
+	}
+	if opts.Club != "" {
+		filtered := classes[:0]
+		for _, classInfo := range classes {
+			if strings.EqualFold(classInfo.ClubName, opts.Club) {
+				filtered = append(filtered, classInfo)
+			}
+		}
+		classes = filtered
+	}
+	if len(classes) == 0 {
+		return fmt.Errorf("no classes found to pick from")
+	}
+
+	out := outputOrStdout(opts.Out)
+	var chosen Class
+	if opts.ClassID != "" {
+		found := false
+		for _, classInfo := range classes {
+			if classInfo.ClassID == opts.ClassID {
+				chosen, found = classInfo, true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("class %s not found in the current schedule", opts.ClassID)
+		}
+	} else {
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		for idx, classInfo := range classes {
+			fmt.Fprintf(w, "%d)\t%s\t%s\t%s\t%s\t%s\n", idx+1, classInfo.ClubName, classInfo.Day, classInfo.Time, classInfo.Title, classInfo.Trainer)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		choice, err := promptChoice(opts.In, out, "Class to add", len(classes))
+		if err != nil {
+			return err
+		}
+		chosen = classes[choice-1]
+	}
+
+	interest, err := interestFromClass(chosen)
+	if err != nil {
+		return err
+	}
+	for _, existing := range profile.Interests[chosen.ClubName] {
+		if interestsEqual(existing, interest) {
+			return fmt.Errorf("%s on %s at %s is already an interest for %s", interest.Title, interest.Day, interest.Time, chosen.ClubName)
+		}
+	}
+
+	if err := addInterestToConfig(opts.ConfigPath, profileIdx, chosen.ClubName, interest); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Added %s (%s %s) to %s interests for profile %s.\n", interest.Title, interest.Day, interest.Time, chosen.ClubName, profile.Name)
+	return nil
+}
+
+// RunInterestsRemove deletes one interest from the config file, chosen by club and number or interactively.
+func RunInterestsRemove(cfg *Config, opts InterestsOptions) error {
+	profileIdx, profile, err := editableProfile(cfg, opts.Profile)
+	if err != nil {
+		return err
+	}
+
+	type entry struct {
+		club  string
+		index int
+	}
+	var entries []entry
+	for _, clubName := range sortedKeys(profile.Interests) {
+		for idx := range profile.Interests[clubName] {
+			entries = append(entries, entry{club: clubName, index: idx})
+		}
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("profile %s has no interests", profile.Name)
+	}
+
+	out := outputOrStdout(opts.Out)
+	var target entry
+	if opts.Club != "" && opts.Index > 0 {
+		interests, ok := profile.Interests[opts.Club]
+		if !ok {
+			return fmt.Errorf("profile %s has no interests for club %q", profile.Name, opts.Club)
+		}
+		if opts.Index > len(interests) {
+			return fmt.Errorf("club %s has %d interests, cannot remove #%d", opts.Club, len(interests), opts.Index)
+		}
+		target = entry{club: opts.Club, index: opts.Index - 1}
+	} else {
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		for idx, e := range entries {
+			interest := profile.Interests[e.club][e.index]
+			fmt.Fprintf(w, "%d)\t%s\t%s\t%s\t%s\n", idx+1, e.club, interestDay(interest), interest.Time, interestLabel(interest))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		choice, err := promptChoice(opts.In, out, "Interest to remove", len(entries))
+		if err != nil {
+			return err
+		}
+		target = entries[choice-1]
+	}
+
+	removed := profile.Interests[target.club][target.index]
+	if err := removeInterestFromConfig(opts.ConfigPath, profileIdx, target.club, target.index); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Removed %s (%s %s) from %s interests for profile %s.\n", interestLabel(removed), interestDay(removed), removed.Time, target.club, profile.Name)
+	return nil
+}
+
+// addInterestToConfig appends interest's day, day_english, time and title under clubName in the interests
+// of the profile at profileIdx, creating the club or interests keys when missing.
+func addInterestToConfig(path string, profileIdx int, clubName string, interest ClassInterest) error {
+	entry := yaml.MapSlice{
+		{Key: "day", Value: interest.Day},
+		{Key: "day_english", Value: interest.DayEnglish},
+		{Key: "time", Value: interest.Time},
+		{Key: "title", Value: interest.Title},
+	}
+
+	return editConfigFile(path, func(file *ast.File) error {
+		parent := profileConfigPath(file, profileIdx)
+		interestsKey := append(parent, "interests")
+		clubPath := configPath(append(interestsKey, clubName)...)
+		if nodeExists(file, clubPath) {
+			node, err := yaml.ValueToNode([]yaml.MapSlice{entry})
+			if err != nil {
+				return err
+			}
+			return clubPath.MergeFromNode(file, node)
+		}
+		if nodeExists(file, configPath(interestsKey...)) {
+			return setNodeKey(file, interestsKey, clubName, []yaml.MapSlice{entry})
+		}
+		return setNodeKey(file, parent, "interests", yaml.MapSlice{{Key: clubName, Value: []yaml.MapSlice{entry}}})
+	})
+}
+
+// removeInterestFromConfig deletes the interest at index under club for the profile at profileIdx, dropping
+// the club key once its list is empty.
+func removeInterestFromConfig(path string, profileIdx int, club string, index int) error {
+	return editConfigFile(path, func(file *ast.File) error {
+		interestsKey := append(profileConfigPath(file, profileIdx), "interests")
+		node, err := configPath(append(interestsKey, club)...).FilterFile(file)
+		if err != nil {
+			return fmt.Errorf("find %s interests: %w", club, err)
+		}
+		seq, ok := node.(*ast.SequenceNode)
+		if !ok {
+			return fmt.Errorf("interests for %s are not a list", club)
+		}
+		if index < 0 || index >= len(seq.Values) {
+			return fmt.Errorf("club %s has %d interests in the config file, cannot remove #%d", club, len(seq.Values), index+1)
+		}
+
+		if len(seq.Values) == 1 {
+			return removeMappingKey(file, interestsKey, club)
+		}
+		seq.Values = removeAt(seq.Values, index)
+		if len(seq.ValueHeadComments) > index {
+			seq.ValueHeadComments = removeAt(seq.ValueHeadComments, index)
+		}
+		if len(seq.Entries) > index {
+			seq.Entries = removeAt(seq.Entries, index)
+		}
+		return nil
+	})
+}
+
+// editableProfile picks the single profile an edit applies to, returning its index in cfg.Profiles.
+func editableProfile(cfg *Config, name string) (int, Profile, error) {
+	if cfg == nil {
+		return 0, Profile{}, fmt.Errorf("configuration is required")
+	}
+	if name == "" {
+		if len(cfg.Profiles) > 1 {
+			return 0, Profile{}, errors.New("several profiles are configured; choose one with --profile")
+		}
+		return 0, cfg.Profiles[0], nil
+	}
+	for idx, profile := range cfg.Profiles {
+		if profile.Name == name {
+			return idx, profile, nil
+		}
+	}
+	return 0, Profile{}, fmt.Errorf("unknown profile %q", name)
+}
+
+// profileConfigPath returns the path segments of the mapping that holds a profile's interests: the
+// document root for a config without profiles, or the profile's entry under profiles.
+func profileConfigPath(file *ast.File, profileIdx int) []any {
+	if nodeExists(file, configPath("profiles")) {
+		return []any{"profiles", profileIdx}
+	}
+	return []any{}
+}
+
+// removeMappingKey drops key from the mapping at parent.
+func removeMappingKey(file *ast.File, parent []any, key string) error {
+	node, err := configPath(parent...).FilterFile(file)
+	if err != nil {
+		return err
+	}
+	mapping, ok := node.(*ast.MappingNode)
+	if !ok {
+		return fmt.Errorf("%s is not a mapping", configPath(parent...))
+	}
+	for idx, value := range mapping.Values {
+		if keyText(value.Key) == key {
+			mapping.Values = removeAt(mapping.Values, idx)
+			if len(mapping.Values) == 0 {
+				empty, err := emptyMapping()
+				if err != nil {
+					return err
+				}
+				return configPath(parent...).ReplaceWithNode(file, empty)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("key %q not found", key)
+}
+
+func keyText(key ast.MapKeyNode) string {
+	if s, ok := key.(*ast.StringNode); ok {
+		return s.Value
+	}
+	return key.String()
+}
+
+func emptyMapping() (ast.Node, error) {
+	node, err := yaml.ValueToNode(map[string]any{})
+	if err != nil {
+		return nil, fmt.Errorf("encode empty mapping: %w", err)
+	}
+	return node, nil
+}
+
+func removeAt[T any](values []T, idx int) []T {
+	return append(values[:idx:idx], values[idx+1:]...)
+}
+
+// interestFromClass derives the day, day_english, time and title fields of an interest from a scraped class.
+func interestFromClass(classInfo Class) (ClassInterest, error) {
+	weekday, ok := dayLabelWeekday(classInfo.Day)
+	if !ok {
+		return ClassInterest{}, fmt.Errorf("cannot tell the weekday of %q", classInfo.Day)
+	}
+	day := classInfo.Day
+	if fields := strings.Fields(day); len(fields) > 0 {
+		// Labels carry the date ("Miercuri 22.10"); only the day name repeats every week.
+		day = fields[0]
+	}
+	return ClassInterest{
+		Day:        day,
+		DayEnglish: weekday.String(),
+		Time:       classInfo.Time,
+		Title:      classInfo.Title,
+	}, nil
+}
+
+func interestDay(interest ClassInterest) string {
+	if interest.Day != "" {
+		return interest.Day
+	}
+	if interest.DayEnglish != "" {
+		return interest.DayEnglish
+	}
+	return strings.Join(interest.Days, ", ")
+}
+
+// promptChoice asks for a number between 1 and max until a valid one is entered.
+func promptChoice(in io.Reader, out io.Writer, label string, max int) (int, error) {
+	if in == nil {
+		in = os.Stdin
+	}
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprintf(out, "%s [1-%d]: ", label, max)
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return 0, err
+			}
+			return 0, errors.New("no selection made")
+		}
+		choice, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+		if err == nil && choice >= 1 && choice <= max {
+			return choice, nil
+		}
+		fmt.Fprintf(out, "Enter a number between 1 and %d.\n", max)
+	}
+}
+
+func outputOrStdout(out io.Writer) io.Writer {
+	if out == nil {
+		return os.Stdout
+	}
+	return out
+}
@@ -0,0 +1,347 @@
+package worldclass
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// romanianDayNames maps the weekday labels rendered by the member site (with and without diacritics) to weekdays.
+var romanianDayNames = map[string]time.Weekday{
+	"luni":     time.Monday,
+	"marti":    time.Tuesday,
+	"marți":    time.Tuesday,
+	"marţi":    time.Tuesday,
+	"miercuri": time.Wednesday,
+	"joi":      time.Thursday,
+	"vineri":   time.Friday,
+	"sambata":  time.Saturday,
+	"sâmbătă":  time.Saturday,
+	"sîmbătă":  time.Saturday,
+	"duminica": time.Sunday,
+	"duminică": time.Sunday,
+}
+
+var patternCache sync.Map
+
+// dayLabelWeekday extracts the weekday from a scraped day label such as "Miercuri 22.10".
+func dayLabelWeekday(label string) (time.Weekday, bool) {
+	for _, field := range strings.FieldsFunc(strings.ToLower(label), func(r rune) bool {
+		return r == ' ' || r == ',' || r == '.' || r == '-' || r == '/'
+	}) {
+		if weekday, ok := romanianDayNames[field]; ok {
+			return weekday, true
+		}
+	}
+	return time.Sunday, false
+}
+
+// interestWeekdays returns every weekday an interest may occur on, combining day_english with the days list.
+func interestWeekdays(interest ClassInterest) ([]time.Weekday, error) {
+	var weekdays []time.Weekday
+	add := func(day time.Weekday) {
+		for _, existing := range weekdays {
+			if existing == day {
+				return
+			}
+		}
+		weekdays = append(weekdays, day)
+	}
+
+	// A one-off interest falls on its date's weekday unless the day is spelled out as well.
+	if interest.Date != "" && strings.TrimSpace(interest.DayEnglish) == "" && len(interest.Days) == 0 {
+		date, err := time.Parse(dateLayout, strings.TrimSpace(interest.Date))
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", interest.Date)
+		}
+		return []time.Weekday{date.Weekday()}, nil
+	}
+
+	if strings.TrimSpace(interest.DayEnglish) != "" || len(interest.Days) == 0 {
+		weekday, err := parseWeekday(interest.DayEnglish)
+		if err != nil {
+			return nil, err
+		}
+		add(weekday)
+	}
+
+	for _, day := range interest.Days {
+		switch strings.ToLower(strings.TrimSpace(day)) {
+		case "weekdays":
+			for d := time.Monday; d <= time.Friday; d++ {
+				add(d)
+			}
+		case "weekend":
+			add(time.Saturday)
+			add(time.Sunday)
+		default:
+			weekday, err := parseWeekday(day)
+			if err != nil {
+				return nil, err
+			}
+			add(weekday)
+		}
+	}
+
+	return weekdays, nil
+}
+
+// interestStartWindow returns the earliest and latest class start, in minutes after midnight, accepted by an interest.
+func interestStartWindow(interest ClassInterest) (int, int, error) {
+	if strings.TrimSpace(interest.Time) != "" {
+		hour, minute, err := parseStartTime(interest.Time)
+		if err != nil {
+			return 0, 0, err
+		}
+		return hour*60 + minute, hour*60 + minute, nil
+	}
+
+	after := strings.TrimSpace(interest.StartAfter)
+	before := strings.TrimSpace(interest.StartBefore)
+	if after == "" && before == "" {
+		return 0, 0, fmt.Errorf("invalid time format %q", interest.Time)
+	}
+	if after == "" {
+		after = before
+	}
+	if before == "" {
+		before = after
+	}
+
+	afterHour, afterMinute, err := parseStartTime(after)
+	if err != nil {
+		return 0, 0, fmt.Errorf("start_after: %w", err)
+	}
+	beforeHour, beforeMinute, err := parseStartTime(before)
+	if err != nil {
+		return 0, 0, fmt.Errorf("start_before: %w", err)
+	}
+
+	earliest, latest := afterHour*60+afterMinute, beforeHour*60+beforeMinute
+	if latest < earliest {
+		return 0, 0, fmt.Errorf("start_before %s is earlier than start_after %s", before, after)
+	}
+
+	return earliest, latest, nil
+}
+
+// validateInterestFilters checks the optional matching fields so mistakes surface when the config is loaded.
+func validateInterestFilters(interest ClassInterest) error {
+	patterns := append([]string{interest.TitleRegex}, interest.Exclude...)
+	for _, pattern := range patterns {
+		if pattern == "" {
+			continue
+		}
+		if _, err := compilePattern(pattern); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	if interest.StartAfter != "" || interest.StartBefore != "" {
+		if _, _, err := interestStartWindow(ClassInterest{StartAfter: interest.StartAfter, StartBefore: interest.StartBefore}); err != nil {
+			return err
+		}
+	}
+
+	for _, day := range interest.Days {
+		if _, err := interestWeekdays(ClassInterest{Days: []string{day}}); err != nil {
+			return err
+		}
+	}
+
+	if interest.Date != "" {
+		date, err := time.Parse(dateLayout, strings.TrimSpace(interest.Date))
+		if err != nil {
+			return fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", interest.Date)
+		}
+		weekdays, err := interestWeekdays(interest)
+		if err != nil {
+			return err
+		}
+		if !containsWeekday(weekdays, date.Weekday()) {
+			return fmt.Errorf("date %s is a %s, which the interest's days exclude", interest.Date, date.Weekday())
+		}
+	}
+	if interest.From != "" || interest.Until != "" {
+		season := firstNonEmpty(interest.From, "0001-01-01") + dateRangeSeparator + firstNonEmpty(interest.Until, "9999-12-31")
+		if _, err := parseDateRange(season); err != nil {
+			return fmt.Errorf("from/until: %w", err)
+		}
+	}
+	if _, err := parseDateRanges(interest.Skip); err != nil {
+		return fmt.Errorf("skip: %w", err)
+	}
+
+	if interest.FallbackAfter != "" {
+		if _, err := time.ParseDuration(interest.FallbackAfter); err != nil {
+			return fmt.Errorf("invalid fallback_after: %w", err)
+		}
+	}
+
+	for idx, fallback := range interest.Fallbacks {
+		if len(fallback.Fallbacks) > 0 {
+			return fmt.Errorf("fallback %d: nested fallbacks are not supported", idx+1)
+		}
+		if err := validateInterestFilters(fallback); err != nil {
+			return fmt.Errorf("fallback %d: %w", idx+1, err)
+		}
+	}
+
+	return nil
+}
+
+// compilePattern compiles a case-insensitive regular expression, caching the result for repeated matching.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if cached, ok := patternCache.Load(pattern); ok {
+		return cached.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return nil, err
+	}
+	patternCache.Store(pattern, re)
+	return re, nil
+}
+
+func matchesPattern(pattern, value string) bool {
+	re, err := compilePattern(pattern)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(value)
+}
+
+// extendedFiltersMatch applies the optional trainer, room, day list, start range, regex and exclusion filters.
+func extendedFiltersMatch(classInfo Class, interest ClassInterest, reference time.Time) bool {
+	if len(interest.Days) > 0 {
+		weekday, ok := dayLabelWeekday(classInfo.Day)
+		if !ok {
+			return false
+		}
+		weekdays, err := interestWeekdays(interest)
+		if err != nil {
+			return false
+		}
+		found := false
+		for _, candidate := range weekdays {
+			if candidate == weekday {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if interest.StartAfter != "" || interest.StartBefore != "" {
+		earliest, latest, err := interestStartWindow(ClassInterest{StartAfter: interest.StartAfter, StartBefore: interest.StartBefore})
+		if err != nil {
+			return false
+		}
+		hour, minute, err := parseStartTime(classInfo.Time)
+		if err != nil {
+			return false
+		}
+		if start := hour*60 + minute; start < earliest || start > latest {
+			return false
+		}
+	}
+
+	if needle := strings.ToLower(strings.TrimSpace(interest.Trainer)); needle != "" && !strings.Contains(strings.ToLower(classInfo.Trainer), needle) {
+		return false
+	}
+
+	if needle := strings.ToLower(strings.TrimSpace(interest.Room)); needle != "" && !strings.Contains(strings.ToLower(classInfo.Room), needle) {
+		return false
+	}
+
+	if !categoryMatches(classInfo, interest.Category) {
+		return false
+	}
+
+	if !classOnActiveDate(classInfo, interest, reference) {
+		return false
+	}
+
+	if interest.TitleRegex != "" && !matchesPattern(interest.TitleRegex, classInfo.Title) {
+		return false
+	}
+
+	for _, pattern := range interest.Exclude {
+		if matchesPattern(pattern, classInfo.Title) || matchesPattern(pattern, classInfo.Trainer) || matchesPattern(pattern, classInfo.Room) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// describe renders the day and time portion of an interest for log lines.
+func (i ClassInterest) describe() string {
+	day := i.Day
+	if day == "" {
+		day = i.DayEnglish
+	}
+	if i.Date != "" {
+		day = strings.TrimSpace(day + " " + i.Date)
+	}
+	if len(i.Days) > 0 {
+		if day != "" {
+			day += ", "
+		}
+		day += strings.Join(i.Days, ", ")
+	}
+
+	timeRange := i.Time
+	if timeRange == "" && (i.StartAfter != "" || i.StartBefore != "") {
+		timeRange = fmt.Sprintf("starting %s-%s", i.StartAfter, i.StartBefore)
+	}
+
+	return fmt.Sprintf("%s | %s", day, timeRange)
+}
+
+// chainLink is one candidate in an interest's fallback chain.
+type chainLink struct {
+	club     string
+	interest ClassInterest
+}
+
+// fallbackChain returns the primary interest followed by its fallbacks, each resolved to the club it targets.
+func fallbackChain(clubName string, interest ClassInterest) []chainLink {
+	chain := []chainLink{{club: clubName, interest: interest}}
+	for _, fallback := range interest.Fallbacks {
+		club := fallback.Club
+		if club == "" {
+			club = clubName
+		}
+		chain = append(chain, chainLink{club: club, interest: fallback})
+	}
+	return chain
+}
+
+// bookedFallback reports the first fallback in chain whose class is already booked.
+func bookedFallback(classes []Class, chain []chainLink, reference time.Time) (int, Class, bool) {
+	for idx := 1; idx < len(chain); idx++ {
+		classInfo, found := findMatchingClass(classes, chain[idx].club, chain[idx].interest, reference)
+		if found && classInfo.Booked {
+			return idx, classInfo, true
+		}
+	}
+	return 0, Class{}, false
+}
+
+// fallbackDue returns when fallbacks may be attempted for interest given the primary's booking window opening.
+func fallbackDue(interest ClassInterest, windowOpen time.Time) time.Time {
+	if windowOpen.IsZero() || interest.FallbackAfter == "" {
+		return windowOpen
+	}
+	delay, err := time.ParseDuration(interest.FallbackAfter)
+	if err != nil {
+		return windowOpen
+	}
+	return windowOpen.Add(delay)
+}
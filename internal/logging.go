@@ -0,0 +1,185 @@
+package worldclass
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// LogOptions selects the level, format and destination of the structured logger.
+type LogOptions struct {
+	// Level is one of debug, info, warn or error.
+	Level string
+	// Format is text or json.
+	Format string
+	// File, when set, receives logs instead of stdout and is rotated once it exceeds MaxSizeMB.
+	File       string
+	MaxSizeMB  int
+	MaxBackups int
+}
+
+// NewLogger builds the slog.Logger used by the CLI. The returned closer releases the log file, if any.
+func NewLogger(opts LogOptions) (*slog.Logger, io.Closer, error) {
+	var level slog.Level
+	switch strings.ToLower(opts.Level) {
+	case "", "info":
+		level = slog.LevelInfo
+	case "debug":
+		level = slog.LevelDebug
+	case "warn", "warning":
+		level = slog.LevelWarn
+	case "error":
+		level = slog.LevelError
+	default:
+		return nil, nil, fmt.Errorf("unknown log level %q", opts.Level)
+	}
+
+	var (
+		out    io.Writer = os.Stdout
+		closer io.Closer = nopCloser{}
+	)
+	if opts.File != "" {
+		writer, err := newRotatingWriter(opts.File, int64(opts.MaxSizeMB)*1024*1024, opts.MaxBackups)
+		if err != nil {
+			return nil, nil, err
+		}
+		out, closer = writer, writer
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch strings.ToLower(opts.Format) {
+	case "", "text":
+		handler = slog.NewTextHandler(out, handlerOpts)
+	case "json":
+		handler = slog.NewJSONHandler(out, handlerOpts)
+	default:
+		return nil, nil, fmt.Errorf("unknown log format %q", opts.Format)
+	}
+
+	return slog.New(handler), closer, nil
+}
+
+func loggerOrDefault(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return slog.Default()
+	}
+	return logger
+}
+
+// classAttrs returns the attributes that identify a scraped class in log records.
+func classAttrs(classInfo Class) []any {
+	attrs := []any{
+		"club", classInfo.ClubName,
+		"day", classInfo.Day,
+		"class_time", classInfo.Time,
+		"title", classInfo.Title,
+		"trainer", classInfo.Trainer,
+		"class_id", classInfo.ClassID,
+	}
+	if classInfo.Category != "" {
+		attrs = append(attrs, "category", classInfo.Category)
+	}
+	return attrs
+}
+
+// interestAttrs returns the attributes that identify a configured interest in log records.
+func interestAttrs(clubName string, interest ClassInterest) []any {
+	return []any{
+		"club", clubName,
+		"interest", interest.describe(),
+		"title", interest.Title,
+	}
+}
+
+// rotatingWriter appends to a log file and rotates it to numbered backups once it grows past maxSize.
+type rotatingWriter struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func newRotatingWriter(path string, maxSize int64, maxBackups int) (*rotatingWriter, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("create log directory: %w", err)
+		}
+	}
+
+	w := &rotatingWriter{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *rotatingWriter) open() error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("stat log file: %w", err)
+	}
+	w.file, w.size = file, info.Size()
+	return nil
+}
+
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// rotate shifts path.N to path.N+1, moves the current file to path.1 and reopens an empty file.
+func (w *rotatingWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("close log file: %w", err)
+	}
+
+	if w.maxBackups <= 0 {
+		if err := os.Remove(w.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("remove log file: %w", err)
+		}
+		return w.open()
+	}
+
+	_ = os.Remove(fmt.Sprintf("%s.%d", w.path, w.maxBackups))
+	for i := w.maxBackups - 1; i >= 1; i-- {
+		_ = os.Rename(fmt.Sprintf("%s.%d", w.path, i), fmt.Sprintf("%s.%d", w.path, i+1))
+	}
+	if err := os.Rename(w.path, w.path+".1"); err != nil {
+		return fmt.Errorf("rotate log file: %w", err)
+	}
+
+	return w.open()
+}
+
+func (w *rotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,170 @@
+package worldclass
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+const (
+	monitorSlugPrefix   = "wc-"
+	monitorSlugMaxLen   = 50
+	monitorCheckInGrace = 5
+)
+
+// initSentry configures the Sentry client and returns its hub, or nil when no DSN is set.
+func initSentry(cfg SentryConfig) (*sentry.Hub, error) {
+	if cfg.DSN == "" {
+		return nil, nil
+	}
+
+	if err := sentry.Init(sentry.ClientOptions{
+		Dsn:         cfg.DSN,
+		Release:     cfg.Release,
+		Environment: cfg.Environment,
+		ServerName:  cfg.ServerName,
+	}); err != nil {
+		return nil, fmt.Errorf("sentry init: %w", err)
+	}
+
+	return sentry.CurrentHub(), nil
+}
+
+func reportLoopError(hub *sentry.Hub, err error, extras map[string]string) {
+	if hub == nil || err == nil {
+		return
+	}
+
+	hub.WithScope(func(scope *sentry.Scope) {
+		scope.SetTag("mode", "loop")
+		for k, v := range extras {
+			scope.SetTag(k, v)
+		}
+		hub.CaptureException(err)
+	})
+}
+
+func reportLoopSuccess(hub *sentry.Hub, classInfo Class) {
+	if hub == nil {
+		return
+	}
+
+	hub.WithScope(func(scope *sentry.Scope) {
+		scope.SetTag("mode", "loop")
+		scope.SetTag("club", classInfo.ClubName)
+		scope.SetTag("title", classInfo.Title)
+		scope.SetTag("time", classInfo.Time)
+		scope.SetContext("class", classContext(classInfo))
+		hub.CaptureMessage("class booked successfully")
+	})
+}
+
+// addBreadcrumb records a step of the fetch/login/book flow so later events show how they were reached.
+func addBreadcrumb(hub *sentry.Hub, category, message string, classInfo *Class) {
+	if hub == nil {
+		return
+	}
+
+	crumb := &sentry.Breadcrumb{
+		Category:  category,
+		Message:   message,
+		Level:     sentry.LevelInfo,
+		Timestamp: time.Now(),
+	}
+	if classInfo != nil {
+		crumb.Data = classContext(*classInfo)
+	}
+	hub.AddBreadcrumb(crumb, nil)
+}
+
+func classContext(classInfo Class) sentry.Context {
+	return sentry.Context{
+		"club_id":   classInfo.ClubID,
+		"club_name": classInfo.ClubName,
+		"class_id":  classInfo.ClassID,
+		"day":       classInfo.Day,
+		"time":      classInfo.Time,
+		"title":     classInfo.Title,
+		"trainer":   classInfo.Trainer,
+		"room":      classInfo.Room,
+		"bookable":  classInfo.Bookable,
+		"booked":    classInfo.Booked,
+	}
+}
+
+// windowCheckIn tracks the Sentry Cron check-in of one booking window; a nil value is a no-op.
+type windowCheckIn struct {
+	hub     *sentry.Hub
+	slug    string
+	id      *sentry.EventID
+	started time.Time
+}
+
+// beginWindowCheckIn reports an in_progress check-in for the booking window of handle. The monitor is
+// upserted with a weekly crontab at the wake time, so Sentry alerts when a window passes without a check-in.
+func beginWindowCheckIn(acct *account, handle *scheduledInterest, wakeTime time.Time, location *time.Location) *windowCheckIn {
+	if acct.hub == nil || acct.disableCheckIns {
+		return nil
+	}
+
+	slug := monitorSlug(acct.profile.Name, handle)
+	wake := wakeTime.In(location)
+	maxRuntime := int64(handle.LastStart.Add(bookingGracePeriod).Sub(wakeTime)/time.Minute) + monitorCheckInGrace
+
+	id := acct.hub.CaptureCheckIn(&sentry.CheckIn{
+		MonitorSlug: slug,
+		Status:      sentry.CheckInStatusInProgress,
+	}, &sentry.MonitorConfig{
+		Schedule:      sentry.CrontabSchedule(fmt.Sprintf("%d %d * * %d", wake.Minute(), wake.Hour(), int(wake.Weekday()))),
+		CheckInMargin: monitorCheckInGrace,
+		MaxRuntime:    maxRuntime,
+		Timezone:      location.String(),
+	})
+
+	return &windowCheckIn{hub: acct.hub, slug: slug, id: id, started: time.Now()}
+}
+
+// finish closes the check-in with the given status.
+func (w *windowCheckIn) finish(status sentry.CheckInStatus) {
+	if w == nil || w.id == nil {
+		return
+	}
+
+	w.hub.CaptureCheckIn(&sentry.CheckIn{
+		ID:          *w.id,
+		MonitorSlug: w.slug,
+		Status:      status,
+		Duration:    time.Since(w.started),
+	}, nil)
+}
+
+// monitorSlug derives a stable Sentry monitor slug for a profile's interest occurrence.
+func monitorSlug(profile string, handle *scheduledInterest) string {
+	full := strings.Join([]string{profile, handle.Club, handle.Interest.describe(), handle.Interest.Title}, "|")
+	hasher := fnv.New32a()
+	_, _ = hasher.Write([]byte(full))
+	suffix := fmt.Sprintf("-%08x", hasher.Sum32())
+
+	var b strings.Builder
+	lastDash := false
+	for _, r := range strings.ToLower(profile + "-" + handle.Club + "-" + handle.Interest.Title) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+			lastDash = false
+		case !lastDash:
+			b.WriteByte('-')
+			lastDash = true
+		}
+	}
+
+	readable := strings.Trim(b.String(), "-")
+	if limit := monitorSlugMaxLen - len(monitorSlugPrefix) - len(suffix); len(readable) > limit {
+		readable = strings.TrimRight(readable[:limit], "-")
+	}
+
+	return monitorSlugPrefix + readable + suffix
+}
@@ -0,0 +1,134 @@
+package worldclass
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	defaultNextCount = 10
+	// nextHorizonWeeks bounds how far ahead the next command looks for occurrences.
+	nextHorizonWeeks = 8
+)
+
+// NextOptions controls the behavior of RunNext.
+type NextOptions struct {
+	Profile string
+	Count   int
+	Out     io.Writer
+}
+
+// RunNext prints the upcoming class starts and booking window openings for every interest.
+func RunNext(cfg *Config, opts NextOptions) error {
+	if cfg == nil {
+		return fmt.Errorf("configuration is required")
+	}
+
+	profiles, err := cfg.SelectProfiles(opts.Profile)
+	if err != nil {
+		return err
+	}
+
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return fmt.Errorf("load timezone %s: %w", cfg.Timezone, err)
+	}
+
+	count := opts.Count
+	if count <= 0 {
+		count = defaultNextCount
+	}
+	out := opts.Out
+	if out == nil {
+		out = os.Stdout
+	}
+
+	now := time.Now().In(location)
+	until := now.AddDate(0, 0, 7*nextHorizonWeeks)
+
+	type row struct {
+		profile string
+		occurrence
+	}
+	var rows []row
+	for _, profile := range profiles {
+		occurrences, err := upcomingOccurrences(profile.Interests, location, now, until)
+		if err != nil {
+			return fmt.Errorf("profile %s: %w", profile.Name, err)
+		}
+		for _, occ := range occurrences {
+			rows = append(rows, row{profile: profile.Name, occurrence: occ})
+		}
+	}
+
+	if len(rows) == 0 {
+		fmt.Fprintln(out, "no upcoming classes for the configured interests")
+		return nil
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].start.Before(rows[j].start)
+	})
+	if len(rows) > count {
+		rows = rows[:count]
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROFILE\tCLUB\tCLASS\tSTARTS\tIN\tWINDOW OPENS\tIN")
+	for _, r := range rows {
+		windowOpens := r.start.Add(-bookingLeadTime)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.profile,
+			r.handle.Club,
+			interestLabel(r.handle.Interest),
+			r.start.Format("Mon 02 Jan 15:04"),
+			formatCountdown(r.start.Sub(now)),
+			windowOpens.Format("Mon 02 Jan 15:04"),
+			formatCountdown(windowOpens.Sub(now)),
+		)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Times are in %s.\n", location)
+	return nil
+}
+
+// interestLabel names an interest by its title filter, falling back to the regex or a wildcard.
+func interestLabel(interest ClassInterest) string {
+	switch {
+	case interest.Title != "":
+		return interest.Title
+	case interest.TitleRegex != "":
+		return "/" + interest.TitleRegex + "/"
+	default:
+		return "(any)"
+	}
+}
+
+// formatCountdown renders a duration as "2d 3h 14m", or "open" when it already passed.
+func formatCountdown(d time.Duration) string {
+	if d <= 0 {
+		return "open"
+	}
+
+	d = d.Round(time.Minute)
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	hours := d / time.Hour
+	d -= hours * time.Hour
+	minutes := d / time.Minute
+
+	if days > 0 {
+		return fmt.Sprintf("%dd %dh %dm", days, hours, minutes)
+	}
+	if hours > 0 {
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	}
+	return fmt.Sprintf("%dm", minutes)
+}
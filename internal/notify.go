@@ -0,0 +1,445 @@
+package worldclass
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTelegramAPIURL = "https://api.telegram.org"
+	defaultSMTPPort       = 587
+	notifyTimeout         = 10 * time.Second
+)
+
+// EventKind identifies what happened in the booking path. There is no waitlist event: the member site offers
+// no waitlist, a full class simply shows no booking button, so a class that stays full until the cutoff ends
+// as window_missed.
+type EventKind string
+
+const (
+	EventBooked         EventKind = "booked"
+	EventBookingFailed  EventKind = "booking_failed"
+	EventWindowMissed   EventKind = "window_missed"
+	EventReminder       EventKind = "reminder"
+	EventCancelDeadline EventKind = "cancel_deadline"
+	// EventClassMoved and EventInterestUnmatched are sent by the loop when the schedule changes under an interest.
+	EventClassMoved        EventKind = "class_moved"
+	EventInterestUnmatched EventKind = "interest_unmatched"
+	// EventPauseCancelled is sent for every booking cancelled because it falls into a pause.
+	EventPauseCancelled EventKind = "pause_cancelled"
+)
+
+// eventKinds lists every event a notifications.events filter may name.
+var eventKinds = []EventKind{
+	EventBooked, EventBookingFailed, EventWindowMissed, EventReminder, EventCancelDeadline,
+	EventClassMoved, EventInterestUnmatched, EventPauseCancelled,
+}
+
+// Notification describes a booking event delivered to every configured Notifier.
+type Notification struct {
+	Event      EventKind `json:"event"`
+	Profile    string    `json:"profile"`
+	Club       string    `json:"club"`
+	Day        string    `json:"day"`
+	Time       string    `json:"time"`
+	Title      string    `json:"title"`
+	ClassID    string    `json:"class_id,omitempty"`
+	Message    string    `json:"message"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// Subject renders a one-line summary suitable for email subjects and push titles.
+func (n Notification) Subject() string {
+	return fmt.Sprintf("[%s] %s: %s", n.Profile, strings.ReplaceAll(string(n.Event), "_", " "), n.Title)
+}
+
+// Text renders the notification as a short human readable message.
+func (n Notification) Text() string {
+	return fmt.Sprintf("%s\n%s | %s | %s\n%s", n.Subject(), n.Club, n.Day, n.Time, n.Message)
+}
+
+// Notifier delivers booking events to an external channel.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// NotificationsConfig configures the optional notification channels.
+type NotificationsConfig struct {
+	// Events restricts delivery to the listed event kinds; empty means every event.
+	Events   []EventKind     `yaml:"events"`
+	Webhooks []WebhookConfig `yaml:"webhooks"`
+	SMTP     *SMTPConfig     `yaml:"smtp"`
+	Ntfy     *NtfyConfig     `yaml:"ntfy"`
+	Telegram *TelegramConfig `yaml:"telegram"`
+}
+
+// WebhookConfig posts each notification as JSON to URL.
+type WebhookConfig struct {
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers"`
+}
+
+// SMTPConfig sends each notification as a plain-text email.
+type SMTPConfig struct {
+	Host     string   `yaml:"host"`
+	Port     int      `yaml:"port"`
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+}
+
+// NtfyConfig publishes each notification to an ntfy-style topic URL.
+type NtfyConfig struct {
+	URL   string `yaml:"url"`
+	Token string `yaml:"token"`
+}
+
+// TelegramConfig sends each notification through the Telegram bot API.
+type TelegramConfig struct {
+	Token  string `yaml:"token"`
+	ChatID string `yaml:"chat_id"`
+	APIURL string `yaml:"api_url"`
+}
+
+// NewNotifier builds a Notifier that fans out to every channel in cfg. It returns nil when none are configured.
+func NewNotifier(cfg NotificationsConfig) (Notifier, error) {
+	for _, event := range cfg.Events {
+		if !slices.Contains(eventKinds, event) {
+			return nil, fmt.Errorf("events: unknown event %q", event)
+		}
+	}
+
+	httpClient := &http.Client{Timeout: notifyTimeout}
+
+	var notifiers []Notifier
+	for i, hook := range cfg.Webhooks {
+		if hook.URL == "" {
+			return nil, fmt.Errorf("webhooks[%d].url must be set", i)
+		}
+		notifiers = append(notifiers, &webhookNotifier{client: httpClient, cfg: hook})
+	}
+
+	if cfg.SMTP != nil {
+		smtpCfg := *cfg.SMTP
+		if smtpCfg.Host == "" || smtpCfg.From == "" || len(smtpCfg.To) == 0 {
+			return nil, errors.New("smtp.host, smtp.from and smtp.to must be set")
+		}
+		if smtpCfg.Port == 0 {
+			smtpCfg.Port = defaultSMTPPort
+		}
+		notifiers = append(notifiers, &smtpNotifier{cfg: smtpCfg})
+	}
+
+	if cfg.Ntfy != nil {
+		if cfg.Ntfy.URL == "" {
+			return nil, errors.New("ntfy.url must be set")
+		}
+		notifiers = append(notifiers, &ntfyNotifier{client: httpClient, cfg: *cfg.Ntfy})
+	}
+
+	if cfg.Telegram != nil {
+		telegramCfg := *cfg.Telegram
+		if telegramCfg.Token == "" || telegramCfg.ChatID == "" {
+			return nil, errors.New("telegram.token and telegram.chat_id must be set")
+		}
+		if telegramCfg.APIURL == "" {
+			telegramCfg.APIURL = defaultTelegramAPIURL
+		}
+		notifiers = append(notifiers, &telegramNotifier{client: httpClient, cfg: telegramCfg})
+	}
+
+	if len(notifiers) == 0 {
+		return nil, nil
+	}
+
+	return &multiNotifier{notifiers: notifiers, events: cfg.Events}, nil
+}
+
+// multiNotifier delivers to every channel concurrently, each bounded by notifyTimeout so a stuck channel
+// cannot eat into another's time, and joins their errors.
+type multiNotifier struct {
+	notifiers []Notifier
+	events    []EventKind
+}
+
+func (m *multiNotifier) Notify(ctx context.Context, n Notification) error {
+	if len(m.events) > 0 {
+		wanted := false
+		for _, event := range m.events {
+			if event == n.Event {
+				wanted = true
+				break
+			}
+		}
+		if !wanted {
+			return nil
+		}
+	}
+
+	errs := make([]error, len(m.notifiers))
+	var wg sync.WaitGroup
+	for i, notifier := range m.notifiers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			channelCtx, cancel := context.WithTimeout(ctx, notifyTimeout)
+			defer cancel()
+			errs[i] = notifier.Notify(channelCtx, n)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+type webhookNotifier struct {
+	client *http.Client
+	cfg    WebhookConfig
+}
+
+func (w *webhookNotifier) Notify(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("encode webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range w.cfg.Headers {
+		req.Header.Set(key, value)
+	}
+
+	return doNotifyRequest(w.client, req, "webhook")
+}
+
+type ntfyNotifier struct {
+	client *http.Client
+	cfg    NtfyConfig
+}
+
+func (t *ntfyNotifier) Notify(ctx context.Context, n Notification) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.cfg.URL, strings.NewReader(fmt.Sprintf("%s | %s | %s\n%s", n.Club, n.Day, n.Time, n.Message)))
+	if err != nil {
+		return fmt.Errorf("build ntfy request: %w", err)
+	}
+	req.Header.Set("Title", n.Subject())
+	req.Header.Set("Tags", string(n.Event))
+	if n.Event == EventBookingFailed || n.Event == EventWindowMissed {
+		req.Header.Set("Priority", "high")
+	}
+	if t.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+t.cfg.Token)
+	}
+
+	return doNotifyRequest(t.client, req, "ntfy")
+}
+
+type telegramNotifier struct {
+	client *http.Client
+	cfg    TelegramConfig
+}
+
+func (t *telegramNotifier) Notify(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(map[string]string{
+		"chat_id": t.cfg.ChatID,
+		"text":    n.Text(),
+	})
+	if err != nil {
+		return fmt.Errorf("encode telegram payload: %w", err)
+	}
+
+	endpoint := strings.TrimRight(t.cfg.APIURL, "/") + "/bot" + t.cfg.Token + "/sendMessage"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build telegram request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return doNotifyRequest(t.client, req, "telegram")
+}
+
+type smtpNotifier struct {
+	cfg SMTPConfig
+}
+
+func (s *smtpNotifier) Notify(ctx context.Context, n Notification) error {
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+
+	var auth smtp.Auth
+	if s.cfg.Username != "" {
+		auth = smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", s.cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(s.cfg.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", n.Subject())
+	fmt.Fprintf(&msg, "Date: %s\r\n", n.OccurredAt.Format(time.RFC1123Z))
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(n.Text(), "\n", "\r\n"))
+	msg.WriteString("\r\n")
+
+	if err := s.send(ctx, addr, auth, msg.Bytes()); err != nil {
+		return fmt.Errorf("smtp send: %w", err)
+	}
+	return nil
+}
+
+// send does what smtp.SendMail does, but bounded by ctx: smtp.SendMail has no timeout and would block the
+// caller for as long as the server keeps the connection open.
+func (s *smtpNotifier) send(ctx context.Context, addr string, auth smtp.Auth, msg []byte) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+
+	client, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.cfg.Host}); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("server does not support AUTH")
+		}
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(s.cfg.From); err != nil {
+		return err
+	}
+	for _, to := range s.cfg.To {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func doNotifyRequest(client *http.Client, req *http.Request, channel string) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		// *url.Error repeats the request URL, which carries the Telegram bot token; only its cause is reported.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("%s request to %s: %w", channel, req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s unexpected status %d", channel, resp.StatusCode)
+	}
+	return nil
+}
+
+// notify delivers an event for classInfo through the account's notifier in the background, so a slow channel
+// cannot hold up a booking; delivery failures are logged. flushNotifications waits for the deliveries.
+func (a *account) notify(event EventKind, classInfo Class, format string, args ...interface{}) {
+	if a.notifier == nil {
+		return
+	}
+
+	n := Notification{
+		Event:      event,
+		Profile:    a.profile.Name,
+		Club:       classInfo.ClubName,
+		Day:        classInfo.Day,
+		Time:       classInfo.Time,
+		Title:      classInfo.Title,
+		ClassID:    classInfo.ClassID,
+		Message:    fmt.Sprintf(format, args...),
+		OccurredAt: a.clock.Now(),
+	}
+
+	a.notifications.Add(1)
+	go func() {
+		defer a.notifications.Done()
+		if err := a.notifier.Notify(context.Background(), n); err != nil {
+			a.log.Warn("notification failed", append(classAttrs(classInfo), "event", event, "error", err)...)
+		}
+	}()
+}
+
+// notifyFailure sends booking_failed for classInfo once per booking window: the loop retries every few seconds
+// until the cutoff, and a message per failed attempt would flood the channels.
+func (a *account) notifyFailure(classInfo Class, format string, args ...interface{}) {
+	key := classInfo.ClubID + "/" + classInfo.ClassID
+	a.failuresMu.Lock()
+	if a.failuresNotified == nil {
+		a.failuresNotified = make(map[string]bool)
+	}
+	sent := a.failuresNotified[key]
+	a.failuresNotified[key] = true
+	a.failuresMu.Unlock()
+
+	if !sent {
+		a.notify(EventBookingFailed, classInfo, format, args...)
+	}
+}
+
+// resetFailureNotices starts a new booking window for notifyFailure.
+func (a *account) resetFailureNotices() {
+	a.failuresMu.Lock()
+	a.failuresNotified = nil
+	a.failuresMu.Unlock()
+}
+
+// flushNotifications waits for notifications still being delivered, at most notifyTimeout, so short-lived
+// commands do not exit before their notifications are sent.
+func (a *account) flushNotifications() {
+	done := make(chan struct{})
+	go func() {
+		a.notifications.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(notifyTimeout):
+		a.log.Warn("gave up waiting for notifications to be delivered")
+	}
+}
@@ -0,0 +1,308 @@
+package worldclass
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testNotification = Notification{
+	Event:      EventBookingFailed,
+	Profile:    "ana",
+	Club:       "Park Lake",
+	Day:        "Luni 19.10",
+	Time:       "18:00 - 19:00",
+	Title:      "PILATES",
+	ClassID:    "4541",
+	Message:    "booking failed: class is full",
+	OccurredAt: time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC),
+}
+
+// capturedRequest is what a stand-in HTTP server received.
+type capturedRequest struct {
+	path   string
+	header http.Header
+	body   string
+}
+
+func newCaptureServer(t *testing.T, status int) (*httptest.Server, <-chan capturedRequest) {
+	t.Helper()
+	requests := make(chan capturedRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- capturedRequest{path: r.URL.Path, header: r.Header.Clone(), body: string(body)}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func notifyOnce(t *testing.T, cfg NotificationsConfig) error {
+	t.Helper()
+	notifier, err := NewNotifier(cfg)
+	if err != nil {
+		t.Fatalf("NewNotifier: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return notifier.Notify(ctx, testNotification)
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	server, requests := newCaptureServer(t, http.StatusNoContent)
+
+	err := notifyOnce(t, NotificationsConfig{Webhooks: []WebhookConfig{{URL: server.URL + "/hook", Headers: map[string]string{"X-Token": "secret"}}}})
+	if err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	req := <-requests
+	if req.path != "/hook" || req.header.Get("X-Token") != "secret" || req.header.Get("Content-Type") != "application/json" {
+		t.Errorf("request = %s with headers %v", req.path, req.header)
+	}
+	var got Notification
+	if err := json.Unmarshal([]byte(req.body), &got); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if got != testNotification {
+		t.Errorf("payload = %+v, want %+v", got, testNotification)
+	}
+}
+
+func TestNtfyNotifier(t *testing.T) {
+	server, requests := newCaptureServer(t, http.StatusOK)
+
+	if err := notifyOnce(t, NotificationsConfig{Ntfy: &NtfyConfig{URL: server.URL + "/bookings", Token: "tk"}}); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	req := <-requests
+	checks := map[string]string{
+		"Title":         testNotification.Subject(),
+		"Tags":          string(EventBookingFailed),
+		"Priority":      "high",
+		"Authorization": "Bearer tk",
+	}
+	for header, want := range checks {
+		if got := req.header.Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+	if req.path != "/bookings" || !strings.Contains(req.body, testNotification.Message) {
+		t.Errorf("request = %s %q", req.path, req.body)
+	}
+}
+
+func TestTelegramNotifier(t *testing.T) {
+	server, requests := newCaptureServer(t, http.StatusOK)
+
+	if err := notifyOnce(t, NotificationsConfig{Telegram: &TelegramConfig{Token: "123:abc", ChatID: "42", APIURL: server.URL}}); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	req := <-requests
+	if req.path != "/bot123:abc/sendMessage" {
+		t.Errorf("path = %s", req.path)
+	}
+	var payload map[string]string
+	if err := json.Unmarshal([]byte(req.body), &payload); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if payload["chat_id"] != "42" || payload["text"] != testNotification.Text() {
+		t.Errorf("payload = %v", payload)
+	}
+}
+
+func TestTelegramNotifierKeepsTokenOutOfErrors(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	apiURL := server.URL
+	// Closing the server makes the request fail at the transport, which reports the URL it was sending to.
+	server.Close()
+
+	err := notifyOnce(t, NotificationsConfig{Telegram: &TelegramConfig{Token: "123:secret-token", ChatID: "42", APIURL: apiURL}})
+	if err == nil {
+		t.Fatal("Notify succeeded against a closed server")
+	}
+	if strings.Contains(err.Error(), "secret-token") {
+		t.Errorf("error leaks the bot token: %v", err)
+	}
+}
+
+func TestHTTPNotifierRejectedStatus(t *testing.T) {
+	server, _ := newCaptureServer(t, http.StatusInternalServerError)
+
+	err := notifyOnce(t, NotificationsConfig{Webhooks: []WebhookConfig{{URL: server.URL}}})
+	if err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("Notify() error = %v, want the unexpected status", err)
+	}
+}
+
+// serveSMTP answers one SMTP session on listener and sends the message data it received.
+func serveSMTP(listener net.Listener, messages chan<- string) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+	reply("220 localhost ESMTP")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case command == "DATA":
+			reply("354 end with .")
+			var data strings.Builder
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			messages <- data.String()
+			reply("250 queued")
+		case command == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+	messages := make(chan string, 1)
+	go serveSMTP(listener, messages)
+
+	addr := listener.Addr().(*net.TCPAddr)
+	err = notifyOnce(t, NotificationsConfig{SMTP: &SMTPConfig{Host: "127.0.0.1", Port: addr.Port, From: "bot@example.com", To: []string{"ana@example.com"}}})
+	if err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	message := <-messages
+	for _, want := range []string{"From: bot@example.com", "To: ana@example.com", "Subject: " + testNotification.Subject(), testNotification.Message} {
+		if !strings.Contains(message, want) {
+			t.Errorf("message lacks %q:\n%s", want, message)
+		}
+	}
+}
+
+func TestSMTPNotifierTimesOut(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+	// Accept the connection but never greet, like a stuck server.
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(2 * time.Second)
+		}
+	}()
+
+	notifier, err := NewNotifier(NotificationsConfig{SMTP: &SMTPConfig{Host: "127.0.0.1", Port: listener.Addr().(*net.TCPAddr).Port, From: "bot@example.com", To: []string{"ana@example.com"}}})
+	if err != nil {
+		t.Fatalf("NewNotifier: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	if err := notifier.Notify(ctx, testNotification); err == nil {
+		t.Fatal("Notify() succeeded against a silent server")
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("Notify() took %s, want it bounded by the context", elapsed)
+	}
+}
+
+func TestNotifierChannelsDoNotWaitForEachOther(t *testing.T) {
+	release := make(chan struct{})
+	stuck := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer stuck.Close()
+	defer close(release)
+	server, requests := newCaptureServer(t, http.StatusOK)
+
+	notifier, err := NewNotifier(NotificationsConfig{Webhooks: []WebhookConfig{{URL: stuck.URL}, {URL: server.URL}}})
+	if err != nil {
+		t.Fatalf("NewNotifier: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- notifier.Notify(ctx, testNotification) }()
+
+	select {
+	case <-requests:
+	case <-time.After(2 * time.Second):
+		t.Fatal("second channel waited for the stuck one")
+	}
+	cancel()
+	if err := <-done; err == nil {
+		t.Error("Notify() hid the stuck channel's error")
+	}
+}
+
+func TestNotifierEventFilter(t *testing.T) {
+	if _, err := NewNotifier(NotificationsConfig{Events: []EventKind{"boked"}, Ntfy: &NtfyConfig{URL: "http://127.0.0.1"}}); err == nil {
+		t.Error("NewNotifier() accepted an unknown event")
+	}
+
+	server, requests := newCaptureServer(t, http.StatusOK)
+	if err := notifyOnce(t, NotificationsConfig{Events: []EventKind{EventBooked}, Webhooks: []WebhookConfig{{URL: server.URL}}}); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	select {
+	case req := <-requests:
+		t.Errorf("filtered event was delivered: %s", req.body)
+	default:
+	}
+}
+
+func TestNotifyFailureOncePerWindow(t *testing.T) {
+	notifier := &recordingNotifier{}
+	acct := newTestAccount(&fakeFetcher{}, &fakeBooker{})
+	acct.notifier = notifier
+	classInfo := Class{ClubID: "454", ClassID: "4541", Title: "PILATES"}
+
+	for attempt := 0; attempt < 5; attempt++ {
+		acct.notifyFailure(classInfo, "attempt %d failed", attempt)
+	}
+	acct.resetFailureNotices()
+	acct.notifyFailure(classInfo, "next window")
+	acct.flushNotifications()
+
+	want := []EventKind{EventBookingFailed, EventBookingFailed}
+	if got := notifier.events(); !slices.Equal(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,353 @@
+package worldclass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	pauseFile       = "pause.json"
+	pauseTimeLayout = "Mon 02 Jan 15:04"
+	// allProfilesPause keys the pause that applies to every profile.
+	allProfilesPause = "*"
+)
+
+// PauseConfig controls what happens to existing bookings when the loop is paused.
+type PauseConfig struct {
+	// CancelBooked cancels booked classes that start while a pause is active: when the pause is set and when
+	// the loop reaches a paused occurrence.
+	CancelBooked bool `yaml:"cancel_booked"`
+}
+
+// pauseEntry is one pause window, stored in the state directory so a running loop picks it up. A profile can
+// hold several, e.g. two trips booked ahead.
+type pauseEntry struct {
+	From   time.Time `json:"from"`
+	Until  time.Time `json:"until"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+func (p pauseEntry) covers(t time.Time) bool {
+	return !t.Before(p.From) && t.Before(p.Until)
+}
+
+func loadPauses(store *stateStore) (map[string][]pauseEntry, error) {
+	pauses := make(map[string][]pauseEntry)
+	if err := store.load(pauseFile, &pauses); err != nil {
+		return nil, err
+	}
+	return pauses, nil
+}
+
+// activePauses returns the entries that have not ended by now, ordered by start.
+func activePauses(entries []pauseEntry, now time.Time) []pauseEntry {
+	var active []pauseEntry
+	for _, entry := range entries {
+		if entry.Until.After(now) {
+			active = append(active, entry)
+		}
+	}
+	sort.SliceStable(active, func(i, j int) bool {
+		return active[i].From.Before(active[j].From)
+	})
+	return active
+}
+
+// pausedAt returns the pause covering t for the account's profile, if any. Read errors are logged and treated
+// as not paused, so a damaged file cannot stop bookings silently.
+func (a *account) pausedAt(t time.Time) (pauseEntry, bool) {
+	pauses, err := loadPauses(a.store)
+	if err != nil {
+		a.log.Warn("could not read pause state", "error", err)
+		return pauseEntry{}, false
+	}
+	for _, key := range []string{a.profile.Name, allProfilesPause} {
+		for _, entry := range pauses[key] {
+			if entry.covers(t) {
+				return entry, true
+			}
+		}
+	}
+	return pauseEntry{}, false
+}
+
+// skipPausedOccurrence handles an occurrence that falls into a pause: the window is recorded as skipped and,
+// with pause.cancel_booked, a booking the account already holds for it is cancelled.
+func (s *Scheduler) skipPausedOccurrence(handle *scheduledInterest, startTime time.Time, pause pauseEntry) {
+	acct := s.acct
+	reason := "paused until " + pause.Until.In(s.location).Format(pauseTimeLayout)
+	acct.log.Info("loop paused; skipping occurrence", append(interestAttrs(handle.Club, handle.Interest), "start", startTime, "until", pause.Until)...)
+	acct.state.setResult("skipped "+interestLabel(handle.Interest)+" at "+handle.Club+": "+reason, s.clock.Now())
+
+	var classInfo Class
+	if acct.pause.CancelBooked && !acct.dryRun {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		classes, err := acct.fetcher.FetchClasses(ctx, acct.profile.Credentials, acct.scheduleClubs())
+		if err != nil {
+			acct.log.Warn("could not check bookings of paused occurrence", append(interestAttrs(handle.Club, handle.Interest), "error", err)...)
+		} else if match, found := findMatchingClass(classes, handle.Club, handle.Interest, s.clock.Now()); found {
+			classInfo = match
+			if match.Booked {
+				cancelPausedBookings(ctx, acct, []Class{match})
+			}
+		}
+	}
+	acct.recordWindow(handle, startTime, windowSkipped, reason, classInfo)
+}
+
+// cancelPausedBookings cancels classes with one booking session, recording each cancellation. It returns how
+// many were cancelled.
+func cancelPausedBookings(ctx context.Context, acct *account, classes []Class) int {
+	if len(classes) == 0 {
+		return 0
+	}
+
+	session, err := acct.booker.Login(ctx, acct.profile.Credentials)
+	if err != nil {
+		acct.log.Error("could not cancel bookings during pause", "error", err)
+		return 0
+	}
+
+	cancelled := 0
+	for _, classInfo := range classes {
+		if err := session.Cancel(ctx, classInfo.CancelURL); err != nil {
+			acct.log.Error("failed cancelling booking during pause", append(classAttrs(classInfo), "phase", "pause", "error", err)...)
+			continue
+		}
+		cancelled++
+		acct.log.Info("cancelled booking during pause", append(classAttrs(classInfo), "phase", "pause")...)
+		acct.notify(EventPauseCancelled, classInfo, "booking cancelled while paused")
+		acct.recordBooking(acct.profile.Name, historyCancelled, classInfo, sourcePause)
+	}
+	return cancelled
+}
+
+// PauseOptions controls the behavior of RunPause.
+type PauseOptions struct {
+	// Profile restricts the pause to one profile; empty pauses every profile.
+	Profile string
+	// From and Until bound the pause; a zero From starts it now.
+	From   time.Time
+	Until  time.Time
+	Reason string
+	// CancelBooked cancels booked classes inside the pause, on top of the pause.cancel_booked setting.
+	CancelBooked bool
+	Out          io.Writer
+	// Clock defaults to the system clock.
+	Clock Clock
+}
+
+// RunPause adds a pause window that running and future booking loops respect, next to the pauses already set,
+// and cancels the booked classes inside it when configured to. Pauses that have ended are dropped.
+func RunPause(cfg *Config, opts PauseOptions) error {
+	if cfg == nil {
+		return fmt.Errorf("configuration is required")
+	}
+	profiles, err := cfg.SelectProfiles(opts.Profile)
+	if err != nil {
+		return err
+	}
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return fmt.Errorf("load timezone %s: %w", cfg.Timezone, err)
+	}
+
+	clock := clockOrDefault(opts.Clock)
+	now := clock.Now()
+	entry := pauseEntry{From: opts.From, Until: opts.Until, Reason: opts.Reason}
+	if entry.From.IsZero() {
+		entry.From = now
+	}
+	if !entry.Until.After(entry.From) || !entry.Until.After(now) {
+		return errors.New("the pause must end in the future and after it starts")
+	}
+
+	store := newStateStore(cfg.StateDir)
+	pauses, err := loadPauses(store)
+	if err != nil {
+		return err
+	}
+	key := opts.Profile
+	if key == "" {
+		key = allProfilesPause
+	}
+	pauses[key] = append(pauses[key], entry)
+	for name, entries := range pauses {
+		if active := activePauses(entries, now); len(active) > 0 {
+			pauses[name] = active
+		} else {
+			delete(pauses, name)
+		}
+	}
+	if err := store.save(pauseFile, pauses); err != nil {
+		return err
+	}
+
+	out := outputOrStdout(opts.Out)
+	fmt.Fprintf(out, "paused %s from %s until %s\n", pauseTarget(key), entry.From.In(location).Format(pauseTimeLayout), entry.Until.In(location).Format(pauseTimeLayout))
+
+	if !opts.CancelBooked && !cfg.Pause.CancelBooked {
+		return nil
+	}
+	for _, profile := range profiles {
+		acct, err := newAccount(cfg, profile, nil, nil)
+		if err != nil {
+			return err
+		}
+		acct.clock = clock
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		classes, err := acct.fetcher.FetchClasses(ctx, profile.Credentials, profile.Clubs)
+		if err != nil {
+			cancel()
+			return fmt.Errorf("profile %s: %w", profile.Name, err)
+		}
+		var inside []Class
+		for _, classInfo := range classes {
+			if !classInfo.Booked {
+				continue
+			}
+			if start, ok := classDate(classInfo, acct.location, now); ok && entry.covers(start) {
+				inside = append(inside, classInfo)
+			}
+		}
+		cancelled := cancelPausedBookings(ctx, acct, inside)
+		cancel()
+		acct.flushNotifications()
+		fmt.Fprintf(out, "%s: cancelled %d of %d booked classes during the pause\n", profile.Name, cancelled, len(inside))
+	}
+	return nil
+}
+
+// ResumeOptions controls the behavior of RunResume.
+type ResumeOptions struct {
+	// Profile lifts only that profile's pause; empty lifts every pause.
+	Profile string
+	Out     io.Writer
+	// Clock defaults to the system clock.
+	Clock Clock
+}
+
+// RunResume removes pause windows so the booking loops book again; a profile's pauses are lifted together.
+func RunResume(cfg *Config, opts ResumeOptions) error {
+	if cfg == nil {
+		return fmt.Errorf("configuration is required")
+	}
+	if _, err := cfg.SelectProfiles(opts.Profile); err != nil {
+		return err
+	}
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return fmt.Errorf("load timezone %s: %w", cfg.Timezone, err)
+	}
+
+	store := newStateStore(cfg.StateDir)
+	pauses, err := loadPauses(store)
+	if err != nil {
+		return err
+	}
+
+	out := outputOrStdout(opts.Out)
+	if opts.Profile == "" {
+		if err := store.save(pauseFile, map[string][]pauseEntry{}); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "resumed %s\n", pauseTarget(allProfilesPause))
+		return nil
+	}
+
+	// A pause of every profile keeps the profile paused, so lifting only its own pause would not resume it.
+	now := clockOrDefault(opts.Clock).Now()
+	all := activePauses(pauses[allProfilesPause], now)
+	paused := len(all) > 0
+	if len(activePauses(pauses[opts.Profile], now)) == 0 {
+		if paused {
+			return fmt.Errorf("%s is paused with every profile until %s; run resume without --profile to lift that pause", opts.Profile, all[0].Until.In(location).Format(pauseTimeLayout))
+		}
+		fmt.Fprintf(out, "%s is not paused\n", opts.Profile)
+		return nil
+	}
+
+	delete(pauses, opts.Profile)
+	if err := store.save(pauseFile, pauses); err != nil {
+		return err
+	}
+	if paused {
+		fmt.Fprintf(out, "lifted the pauses of %s, which stays paused with every profile until %s; run resume without --profile to lift that pause\n", opts.Profile, all[0].Until.In(location).Format(pauseTimeLayout))
+		return nil
+	}
+	fmt.Fprintf(out, "resumed %s\n", opts.Profile)
+	return nil
+}
+
+// RunPauseStatus lists the pauses that have not ended yet.
+func RunPauseStatus(cfg *Config, out io.Writer) error {
+	if cfg == nil {
+		return fmt.Errorf("configuration is required")
+	}
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return fmt.Errorf("load timezone %s: %w", cfg.Timezone, err)
+	}
+	pauses, err := loadPauses(newStateStore(cfg.StateDir))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	out = outputOrStdout(out)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	listed := false
+	for _, key := range sortedKeys(pauses) {
+		for _, entry := range activePauses(pauses[key], now) {
+			if !listed {
+				fmt.Fprintln(w, "PROFILE\tFROM\tUNTIL\tREASON")
+				listed = true
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", pauseTarget(key), entry.From.In(location).Format(pauseTimeLayout), entry.Until.In(location).Format(pauseTimeLayout), entry.Reason)
+		}
+	}
+	if !listed {
+		_, err := fmt.Fprintln(out, "not paused")
+		return err
+	}
+	return w.Flush()
+}
+
+func pauseTarget(key string) string {
+	if key == allProfilesPause {
+		return "all profiles"
+	}
+	return key
+}
+
+// ParsePauseTime reads a pause bound as YYYY-MM-DD, "YYYY-MM-DD HH:MM" or RFC 3339 in the config's timezone.
+// A bare date used as the end of a pause includes that whole day.
+func ParsePauseTime(cfg *Config, raw string, end bool) (time.Time, error) {
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("load timezone %s: %w", cfg.Timezone, err)
+	}
+
+	raw = strings.TrimSpace(raw)
+	if t, err := time.ParseInLocation(dateLayout, raw, location); err == nil {
+		if end {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", raw, location); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (expected YYYY-MM-DD, \"YYYY-MM-DD HH:MM\" or RFC 3339)", raw)
+}
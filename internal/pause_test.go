@@ -0,0 +1,131 @@
+package worldclass
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunResumeProfile(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	pause := pauseEntry{From: now.Add(-time.Hour), Until: now.Add(48 * time.Hour)}
+	expired := pauseEntry{From: now.Add(-48 * time.Hour), Until: now.Add(-time.Hour)}
+
+	tests := []struct {
+		name     string
+		pauses   map[string][]pauseEntry
+		wantErr  bool
+		wantOut  string
+		wantKeys []string
+	}{
+		{
+			name:    "own pause",
+			pauses:  map[string][]pauseEntry{"ana": {pause}},
+			wantOut: "resumed ana",
+		},
+		{
+			name:     "only every profile paused",
+			pauses:   map[string][]pauseEntry{allProfilesPause: {pause}},
+			wantErr:  true,
+			wantKeys: []string{allProfilesPause},
+		},
+		{
+			name:     "own pause under a pause of every profile",
+			pauses:   map[string][]pauseEntry{"ana": {pause, expired}, allProfilesPause: {pause}},
+			wantOut:  "stays paused with every profile",
+			wantKeys: []string{allProfilesPause},
+		},
+		{
+			name:     "expired pause of every profile",
+			pauses:   map[string][]pauseEntry{"ana": {expired}, allProfilesPause: {expired}},
+			wantOut:  "ana is not paused",
+			wantKeys: []string{allProfilesPause, "ana"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{StateDir: t.TempDir(), Profiles: []Profile{{Name: "ana"}, {Name: "dan"}}}
+			store := newStateStore(cfg.StateDir)
+			if err := store.save(pauseFile, tt.pauses); err != nil {
+				t.Fatal(err)
+			}
+
+			var out bytes.Buffer
+			err := RunResume(cfg, ResumeOptions{Profile: "ana", Out: &out, Clock: &fakeClock{now: now}})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RunResume error = %v, want error %v", err, tt.wantErr)
+			}
+			if !strings.Contains(out.String(), tt.wantOut) {
+				t.Errorf("output %q, want %q", out.String(), tt.wantOut)
+			}
+			if strings.Contains(out.String(), "resumed") && tt.wantOut != "resumed ana" {
+				t.Errorf("output %q claims the profile resumed", out.String())
+			}
+
+			pauses, err := loadPauses(store)
+			if err != nil {
+				t.Fatal(err)
+			}
+			keys := sortedKeys(pauses)
+			if !slices.Equal(keys, tt.wantKeys) {
+				t.Errorf("pauses left %v, want %v", keys, tt.wantKeys)
+			}
+		})
+	}
+}
+
+func TestRunPauseKeepsEarlierPauses(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Bucharest")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	trips := []pauseEntry{
+		{From: time.Date(2099, 11, 2, 0, 0, 0, 0, location), Until: time.Date(2099, 11, 6, 0, 0, 0, 0, location), Reason: "conference"},
+		{From: time.Date(2099, 12, 21, 0, 0, 0, 0, location), Until: time.Date(2099, 12, 28, 0, 0, 0, 0, location), Reason: "holidays"},
+	}
+	cfg := &Config{StateDir: t.TempDir(), Timezone: "Europe/Bucharest", Profiles: []Profile{{Name: "ana"}}}
+	store := newStateStore(cfg.StateDir)
+	if err := store.save(pauseFile, map[string][]pauseEntry{"ana": {{From: now.Add(-48 * time.Hour), Until: now.Add(-time.Hour)}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	for _, trip := range trips {
+		opts := PauseOptions{Profile: "ana", From: trip.From.UTC(), Until: trip.Until.UTC(), Reason: trip.Reason, Out: &out, Clock: &fakeClock{now: now}}
+		if err := RunPause(cfg, opts); err != nil {
+			t.Fatalf("RunPause: %v", err)
+		}
+	}
+	if err := RunPauseStatus(cfg, &out); err != nil {
+		t.Fatalf("RunPauseStatus: %v", err)
+	}
+
+	pauses, err := loadPauses(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(pauses["ana"]); got != len(trips) {
+		t.Errorf("%d pauses stored, want %d without the expired one", got, len(trips))
+	}
+	acct := newTestAccount(&fakeFetcher{}, &fakeBooker{})
+	acct.profile.Name = "ana"
+	acct.store = store
+	for _, trip := range trips {
+		if _, paused := acct.pausedAt(trip.From.Add(time.Hour)); !paused {
+			t.Errorf("not paused during the %s", trip.Reason)
+		}
+		// Times are shown in the configured zone: local midnight, not 22:00 UTC the day before.
+		for _, want := range []string{trip.From.Format(pauseTimeLayout), trip.Until.Format(pauseTimeLayout)} {
+			if strings.Count(out.String(), want) != 2 {
+				t.Errorf("output lacks %q from pause and status:\n%s", want, out.String())
+			}
+		}
+	}
+	if _, paused := acct.pausedAt(trips[0].Until.Add(time.Hour)); paused {
+		t.Error("paused between the two pauses")
+	}
+}
@@ -0,0 +1,178 @@
+package worldclass
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	defaultReminderRefresh = 30 * time.Minute
+	reminderTick           = time.Minute
+)
+
+// RemindersConfig controls the reminders sent for booked classes in loop mode.
+type RemindersConfig struct {
+	// BeforeStart lists how long before a booked class starts to send a reminder (e.g. ["24h", "2h"]).
+	BeforeStart []string `yaml:"before_start"`
+	// CancellationCutoff is how long before the start late cancellations are penalised (e.g. "3h").
+	CancellationCutoff string `yaml:"cancellation_cutoff"`
+	// BeforeCutoff is how long before the cancellation cutoff to send a reminder (e.g. "1h").
+	BeforeCutoff string `yaml:"before_cutoff"`
+	// Refresh is how often booked classes are re-fetched; defaults to 30m.
+	Refresh string `yaml:"refresh"`
+	// DashboardURL is where the serve dashboard is reachable (e.g. "https://gym.example.com"). When set, the
+	// cancellation cutoff reminder asks whether to keep the class and links to the dashboard to cancel it.
+	DashboardURL string `yaml:"dashboard_url"`
+}
+
+// Enabled reports whether any reminder is configured.
+func (r RemindersConfig) Enabled() bool {
+	return len(r.BeforeStart) > 0 || r.CancellationCutoff != ""
+}
+
+func (r RemindersConfig) validate() error {
+	for _, raw := range r.BeforeStart {
+		if _, err := time.ParseDuration(raw); err != nil {
+			return fmt.Errorf("invalid before_start %q: %w", raw, err)
+		}
+	}
+	for name, raw := range map[string]string{
+		"cancellation_cutoff": r.CancellationCutoff,
+		"before_cutoff":       r.BeforeCutoff,
+		"refresh":             r.Refresh,
+	} {
+		if raw == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(raw); err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	if r.DashboardURL != "" {
+		if u, err := url.Parse(r.DashboardURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid dashboard_url %q: expected an absolute URL", r.DashboardURL)
+		}
+	}
+	return nil
+}
+
+// dashboardLink returns the dashboard page of profile, where booked classes can be cancelled, or "" when no
+// dashboard_url is configured.
+func (r RemindersConfig) dashboardLink(profile string) string {
+	if r.DashboardURL == "" {
+		return ""
+	}
+	return strings.TrimRight(r.DashboardURL, "/") + "/?" + url.Values{"profile": {profile}}.Encode()
+}
+
+// reminder is a single notification due at a fixed time for a booked class.
+type reminder struct {
+	key     string
+	due     time.Time
+	expires time.Time
+	event   EventKind
+	class   Class
+	message string
+}
+
+// runReminders watches the account's booked classes and sends reminders until ctx is cancelled.
+func runReminders(ctx context.Context, acct *account, cfg RemindersConfig, location *time.Location) {
+	refresh := defaultReminderRefresh
+	if cfg.Refresh != "" {
+		refresh, _ = time.ParseDuration(cfg.Refresh)
+	}
+
+	// sent maps the key of each reminder sent to when it expires; expired keys are dropped on refresh.
+	sent := make(map[string]time.Time)
+	var (
+		pending     []reminder
+		lastRefresh time.Time
+	)
+
+	for {
+		now := acct.clock.Now().In(location)
+		if lastRefresh.IsZero() || now.Sub(lastRefresh) >= refresh {
+			fetchCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			classes, err := acct.fetcher.FetchClasses(fetchCtx, acct.profile.Credentials, acct.displayClubs())
+			cancel()
+			if err != nil {
+				acct.log.Error("reminder refresh failed", "phase", "reminders", "error", err)
+				reportLoopError(acct.hub, err, map[string]string{"phase": "reminders"})
+			} else {
+				pending = buildReminders(cfg, acct.profile.Name, classes, location, now)
+			}
+			maps.DeleteFunc(sent, func(_ string, expires time.Time) bool { return !now.Before(expires) })
+			lastRefresh = now
+		}
+
+		for _, r := range pending {
+			if _, done := sent[r.key]; done || now.Before(r.due) || !now.Before(r.expires) {
+				continue
+			}
+			sent[r.key] = r.expires
+			acct.log.Info("reminder", append(classAttrs(r.class), "phase", "reminders", "event", r.event, "message", r.message)...)
+			acct.notify(r.event, r.class, "%s", r.message)
+		}
+
+		if acct.clock.Sleep(ctx, reminderTick) != nil {
+			return
+		}
+	}
+}
+
+// buildReminders lists the reminders due for every booked class of profile in classes.
+func buildReminders(cfg RemindersConfig, profile string, classes []Class, location *time.Location, now time.Time) []reminder {
+	var cutoff, beforeCutoff time.Duration
+	if cfg.CancellationCutoff != "" {
+		cutoff, _ = time.ParseDuration(cfg.CancellationCutoff)
+		if cfg.BeforeCutoff != "" {
+			beforeCutoff, _ = time.ParseDuration(cfg.BeforeCutoff)
+		}
+	}
+
+	var reminders []reminder
+	for _, classInfo := range classes {
+		if !classInfo.Booked {
+			continue
+		}
+		start, ok := classDate(classInfo, location, now)
+		if !ok {
+			continue
+		}
+		base := fmt.Sprintf("%s/%s/%s", classInfo.ClubID, classInfo.ClassID, start.Format(time.RFC3339))
+
+		for _, raw := range cfg.BeforeStart {
+			lead, _ := time.ParseDuration(raw)
+			reminders = append(reminders, reminder{
+				key:     base + "/start/" + raw,
+				due:     start.Add(-lead),
+				expires: start,
+				event:   EventReminder,
+				class:   classInfo,
+				message: fmt.Sprintf("class starts at %s", start.Format("Mon 15:04")),
+			})
+		}
+
+		if cutoff > 0 {
+			deadline := start.Add(-cutoff)
+			message := fmt.Sprintf("cancel before %s to avoid a no-show penalty", deadline.Format("Mon 15:04"))
+			if link := cfg.dashboardLink(profile); link != "" {
+				message += fmt.Sprintf(". Still going? Nothing to do to keep it; to cancel, open %s", link)
+			}
+			reminders = append(reminders, reminder{
+				key:     base + "/cutoff",
+				due:     deadline.Add(-beforeCutoff),
+				expires: deadline,
+				event:   EventCancelDeadline,
+				class:   classInfo,
+				message: message,
+			})
+		}
+	}
+
+	return reminders
+}
@@ -0,0 +1,41 @@
+package worldclass
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildReminders(t *testing.T) {
+	// Sunday 18 October 2026; the schedule lists Monday the 19th and Monday the 26th.
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	cfg := RemindersConfig{BeforeStart: []string{"2h"}, CancellationCutoff: "3h", DashboardURL: "https://gym.example.com/"}
+	classes := []Class{
+		{ClubID: "454", ClassID: "1", Day: "Luni 19.10", Time: "18:00 - 19:00", Title: "PILATES", Booked: true},
+		{ClubID: "454", ClassID: "2", Day: "Luni 26.10", Time: "18:00 - 19:00", Title: "PILATES", Booked: true},
+		{ClubID: "454", ClassID: "3", Day: "Luni 19.10", Time: "19:00 - 20:00", Title: "BODYPUMP"},
+	}
+
+	due := map[string]time.Time{}
+	for _, r := range buildReminders(cfg, "test", classes, time.UTC, now) {
+		due[r.class.ClassID+"/"+string(r.event)] = r.due
+		if r.event == EventCancelDeadline && !strings.Contains(r.message, "https://gym.example.com/?profile=test") {
+			t.Errorf("cancel deadline message %q does not link to the dashboard", r.message)
+		}
+	}
+
+	want := map[string]time.Time{
+		"1/" + string(EventReminder):       time.Date(2026, 10, 19, 16, 0, 0, 0, time.UTC),
+		"1/" + string(EventCancelDeadline): time.Date(2026, 10, 19, 15, 0, 0, 0, time.UTC),
+		"2/" + string(EventReminder):       time.Date(2026, 10, 26, 16, 0, 0, 0, time.UTC),
+		"2/" + string(EventCancelDeadline): time.Date(2026, 10, 26, 15, 0, 0, 0, time.UTC),
+	}
+	if len(due) != len(want) {
+		t.Fatalf("got reminders %v, want %v", due, want)
+	}
+	for key, wantDue := range want {
+		if got := due[key]; !got.Equal(wantDue) {
+			t.Errorf("%s due %v, want %v", key, got, wantDue)
+		}
+	}
+}
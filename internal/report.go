@@ -0,0 +1,486 @@
+package worldclass
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"io"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const historyFile = "bookings.jsonl"
+
+// Booking history events stored in historyRecord.Event.
+const (
+	historyBooked    = "booked"
+	historyCancelled = "cancelled"
+)
+
+// Where a booking history event was observed, stored in historyRecord.Source.
+const (
+	sourceLoop      = "loop"
+	sourceDashboard = "dashboard"
+	sourceTUI       = "tui"
+	sourceScraped   = "scraped"
+	sourcePause     = "pause"
+)
+
+// Report periods and output formats accepted by RunReport.
+const (
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+// historyRecord is one booking or cancellation of a class by a profile.
+type historyRecord struct {
+	Profile  string    `json:"profile"`
+	Club     string    `json:"club"`
+	Title    string    `json:"title"`
+	Trainer  string    `json:"trainer,omitempty"`
+	Category string    `json:"category,omitempty"`
+	ClassID  string    `json:"class_id"`
+	Start    time.Time `json:"start"`
+	Event    string    `json:"event"`
+	Source   string    `json:"source"`
+	At       time.Time `json:"at"`
+}
+
+// key identifies the class occurrence a record belongs to; class IDs are reused every week.
+func (r historyRecord) key() string {
+	return r.Profile + "\t" + r.ClassID + "\t" + r.Start.UTC().Format(time.RFC3339)
+}
+
+// recordBooking stores a booking or cancellation made for profile; failures are logged and otherwise ignored.
+func (a *account) recordBooking(profile, event string, classInfo Class, source string) {
+	if a.store == nil {
+		return
+	}
+	start, ok := classDate(classInfo, a.location, a.clock.Now())
+	if !ok {
+		a.log.Warn("could not record booking: unknown class start", classAttrs(classInfo)...)
+		return
+	}
+	if err := a.store.append(historyFile, newHistoryRecord(profile, event, classInfo, start, source, a.clock.Now())); err != nil {
+		a.log.Warn("could not record booking", "error", err)
+	}
+}
+
+func newHistoryRecord(profile, event string, classInfo Class, start time.Time, source string, at time.Time) historyRecord {
+	return historyRecord{
+		Profile:  profile,
+		Club:     classInfo.ClubName,
+		Title:    classInfo.Title,
+		Trainer:  classInfo.Trainer,
+		Category: classInfo.Category,
+		ClassID:  classInfo.ClassID,
+		Start:    start,
+		Event:    event,
+		Source:   source,
+		At:       at,
+	}
+}
+
+// syncProfileBookings scrapes the booked classes of every profile and returns history extended with the
+// bookings it did not hold yet, which are also stored. Profiles whose schedule cannot be fetched are skipped.
+func syncProfileBookings(cfg *Config, profiles []Profile, logger *slog.Logger, history []historyRecord) ([]historyRecord, error) {
+	for _, profile := range profiles {
+		acct, err := newAccount(cfg, profile, nil, logger)
+		if err != nil {
+			return nil, err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		classes, err := acct.fetcher.FetchClasses(ctx, profile.Credentials, profile.Clubs)
+		cancel()
+		if err != nil {
+			acct.log.Warn("could not scrape booked classes; reporting from history only", "error", err)
+			continue
+		}
+		added, err := syncScrapedBookings(acct, classes, history)
+		history = append(history, added...)
+		if err != nil {
+			return nil, fmt.Errorf("record scraped bookings: %w", err)
+		}
+	}
+	return history, nil
+}
+
+// syncScrapedBookings reconciles the history with the profile's schedule as scraped now: booked classes the
+// history does not hold yet, such as ones booked on the website, are recorded, and upcoming classes the history
+// holds as booked but the website does not are recorded as cancelled. It returns the records it added.
+func syncScrapedBookings(acct *account, classes []Class, history []historyRecord) ([]historyRecord, error) {
+	last := latestEvents(history)
+	now := acct.clock.Now()
+
+	var added []historyRecord
+	for _, classInfo := range classes {
+		if classInfo.ClassID == "" {
+			continue
+		}
+		start, ok := classDate(classInfo, acct.location, now)
+		if !ok {
+			continue
+		}
+
+		record := newHistoryRecord(acct.profile.Name, historyBooked, classInfo, start, sourceScraped, now)
+		previous, seen := last[record.key()]
+		switch {
+		case classInfo.Booked && (!seen || previous.Event != historyBooked):
+		case !classInfo.Booked && seen && previous.Event == historyBooked && start.After(now):
+			record.Event = historyCancelled
+		default:
+			continue
+		}
+
+		if err := acct.store.append(historyFile, record); err != nil {
+			return added, err
+		}
+		added = append(added, record)
+	}
+	return added, nil
+}
+
+// latestEvents returns the last history record of every class occurrence.
+func latestEvents(history []historyRecord) map[string]historyRecord {
+	last := make(map[string]historyRecord, len(history))
+	for _, record := range history {
+		last[record.key()] = record
+	}
+	return last
+}
+
+// ReportOptions controls the behavior of RunReport.
+type ReportOptions struct {
+	Profile string
+	// Period is PeriodWeek or PeriodMonth; Periods is how many of them, counting back from the current one.
+	Period  string
+	Periods int
+	Format  string
+	// Sync first logs in and adds the booked classes scraped from the site to the history, so bookings made on
+	// the website are counted too. Without it the report only reads state_dir.
+	Sync   bool
+	Out    io.Writer
+	Logger *slog.Logger
+}
+
+// report is the rendered content of RunReport, shared by the Markdown and HTML outputs.
+type report struct {
+	Title     string
+	Range     string
+	Generated string
+	Profiles  []reportProfile
+}
+
+type reportProfile struct {
+	Name    string
+	Streaks string
+	Tables  []reportTable
+}
+
+type reportTable struct {
+	Heading string
+	Headers []string
+	Rows    [][]string
+	Empty   string
+}
+
+// RunReport summarises the booking history per week or month: classes attended per type, trainer and club,
+// cancellations, missed booking windows and attendance streaks. Booked classes are scraped first so bookings
+// made outside the scheduler are counted too.
+func RunReport(cfg *Config, opts ReportOptions) error {
+	if cfg == nil {
+		return fmt.Errorf("configuration is required")
+	}
+
+	period := opts.Period
+	if period == "" {
+		period = PeriodWeek
+	}
+	if period != PeriodWeek && period != PeriodMonth {
+		return fmt.Errorf("unsupported period %q (expected week or month)", period)
+	}
+	format := opts.Format
+	if format == "" {
+		format = FormatMarkdown
+	}
+	if format != FormatMarkdown && format != FormatHTML {
+		return fmt.Errorf("unsupported report format %q (expected markdown or html)", format)
+	}
+	periods := opts.Periods
+	if periods <= 0 {
+		periods = 4
+	}
+
+	profiles, err := cfg.SelectProfiles(opts.Profile)
+	if err != nil {
+		return err
+	}
+
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return fmt.Errorf("load timezone %s: %w", cfg.Timezone, err)
+	}
+
+	logger := loggerOrDefault(opts.Logger)
+	store := newStateStore(cfg.StateDir)
+	history, err := readRecords[historyRecord](store, historyFile)
+	if err != nil {
+		return err
+	}
+
+	if opts.Sync {
+		if history, err = syncProfileBookings(cfg, profiles, logger, history); err != nil {
+			return err
+		}
+	}
+
+	windows, err := readRecords[windowRecord](store, windowsFile)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().In(location)
+	buckets := periodStarts(period, periods, now)
+	doc := report{
+		Title:     fmt.Sprintf("Class report by %s", period),
+		Range:     fmt.Sprintf("%s to %s", buckets[0].Format("2006-01-02"), now.Format("2006-01-02")),
+		Generated: now.Format("2006-01-02 15:04 MST"),
+	}
+	last := latestEvents(history)
+	for _, profile := range profiles {
+		doc.Profiles = append(doc.Profiles, buildProfileReport(profile.Name, period, buckets, last, windows, now))
+	}
+
+	out := outputOrStdout(opts.Out)
+	if format == FormatHTML {
+		return writeReportHTML(out, doc)
+	}
+	return writeReportMarkdown(out, doc)
+}
+
+// periodStarts returns the start of the current week or month and of the count-1 before it, oldest first.
+func periodStarts(period string, count int, now time.Time) []time.Time {
+	current := periodStart(period, now)
+	starts := make([]time.Time, count)
+	for i := range starts {
+		starts[i] = shiftPeriod(period, current, i-count+1)
+	}
+	return starts
+}
+
+// periodStart returns midnight of the Monday starting now's week, or of the first day of its month.
+func periodStart(period string, now time.Time) time.Time {
+	if period == PeriodMonth {
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	}
+	offset := (int(now.Weekday()) + 6) % 7
+	return time.Date(now.Year(), now.Month(), now.Day()-offset, 0, 0, 0, 0, now.Location())
+}
+
+func shiftPeriod(period string, start time.Time, n int) time.Time {
+	if period == PeriodMonth {
+		return start.AddDate(0, n, 0)
+	}
+	return start.AddDate(0, 0, 7*n)
+}
+
+func periodLabel(period string, start time.Time) string {
+	if period == PeriodMonth {
+		return start.Format("January 2006")
+	}
+	year, week := start.ISOWeek()
+	return fmt.Sprintf("%d-W%02d (%s)", year, week, start.Format("02 Jan"))
+}
+
+func buildProfileReport(name, period string, buckets []time.Time, last map[string]historyRecord, windows []windowRecord, now time.Time) reportProfile {
+	from := buckets[0]
+	index := func(t time.Time) int {
+		if t.Before(from) || t.After(now) {
+			return -1
+		}
+		idx := len(buckets) - 1
+		for idx > 0 && t.Before(buckets[idx]) {
+			idx--
+		}
+		return idx
+	}
+
+	attended := make([]int, len(buckets))
+	cancelled := make([]int, len(buckets))
+	missed := make([]int, len(buckets))
+	byTitle := make(map[string]int)
+	byTrainer := make(map[string]int)
+	byClub := make(map[string]int)
+	attendedPeriods := make(map[time.Time]bool)
+
+	for _, record := range last {
+		if record.Profile != name {
+			continue
+		}
+		start := record.Start.In(now.Location())
+		if record.Event == historyBooked && !start.After(now) {
+			attendedPeriods[periodStart(period, start)] = true
+		}
+
+		idx := index(start)
+		if idx < 0 {
+			continue
+		}
+		switch record.Event {
+		case historyBooked:
+			attended[idx]++
+			byTitle[record.Title]++
+			byTrainer[record.Trainer]++
+			byClub[record.Club]++
+		case historyCancelled:
+			cancelled[idx]++
+		}
+	}
+	for _, record := range windows {
+		if record.Profile != name || record.Outcome != windowMissed {
+			continue
+		}
+		if idx := index(record.Start.In(now.Location())); idx >= 0 {
+			missed[idx]++
+		}
+	}
+
+	summary := reportTable{
+		Heading: "Per " + period,
+		Headers: []string{strings.ToUpper(period[:1]) + period[1:], "Attended", "Cancelled", "Missed windows"},
+	}
+	for i := len(buckets) - 1; i >= 0; i-- {
+		summary.Rows = append(summary.Rows, []string{
+			periodLabel(period, buckets[i]),
+			strconv.Itoa(attended[i]),
+			strconv.Itoa(cancelled[i]),
+			strconv.Itoa(missed[i]),
+		})
+	}
+
+	current, longest := attendanceStreaks(period, attendedPeriods, now)
+	return reportProfile{
+		Name:    name,
+		Streaks: fmt.Sprintf("Current streak: %s with at least one class. Longest: %s.", countPeriods(current, period), countPeriods(longest, period)),
+		Tables: []reportTable{
+			summary,
+			countTable("By class", "Class", byTitle),
+			countTable("By trainer", "Trainer", byTrainer),
+			countTable("By club", "Club", byClub),
+		},
+	}
+}
+
+func countPeriods(n int, period string) string {
+	if n == 1 {
+		return "1 " + period
+	}
+	return fmt.Sprintf("%d %ss", n, period)
+}
+
+// countTable lists attended classes per name, most attended first.
+func countTable(heading, label string, counts map[string]int) reportTable {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	table := reportTable{Heading: heading, Headers: []string{label, "Attended"}, Empty: "No classes attended."}
+	for _, name := range names {
+		display := name
+		if strings.TrimSpace(display) == "" {
+			display = "(unknown)"
+		}
+		table.Rows = append(table.Rows, []string{display, strconv.Itoa(counts[name])})
+	}
+	return table
+}
+
+// attendanceStreaks counts consecutive periods with at least one attended class. The current streak may
+// still be extended this period, so it counts back from the previous period when this one has none yet.
+func attendanceStreaks(period string, attended map[time.Time]bool, now time.Time) (current, longest int) {
+	starts := make([]time.Time, 0, len(attended))
+	for start := range attended {
+		starts = append(starts, start)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+
+	run := 0
+	for i, start := range starts {
+		if i > 0 && shiftPeriod(period, starts[i-1], 1).Equal(start) {
+			run++
+		} else {
+			run = 1
+		}
+		longest = max(longest, run)
+	}
+
+	cursor := periodStart(period, now)
+	if !attended[cursor] {
+		cursor = shiftPeriod(period, cursor, -1)
+	}
+	for attended[cursor] {
+		current++
+		cursor = shiftPeriod(period, cursor, -1)
+	}
+	return current, longest
+}
+
+func writeReportMarkdown(out io.Writer, doc report) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n%s · generated %s\n", doc.Title, doc.Range, doc.Generated)
+	for _, profile := range doc.Profiles {
+		fmt.Fprintf(&b, "\n## %s\n\n%s\n", markdownEscape(profile.Name), profile.Streaks)
+		for _, table := range profile.Tables {
+			fmt.Fprintf(&b, "\n### %s\n\n", table.Heading)
+			if len(table.Rows) == 0 {
+				fmt.Fprintln(&b, table.Empty)
+				continue
+			}
+			writeMarkdownRow(&b, table.Headers)
+			separators := make([]string, len(table.Headers))
+			for i := range separators {
+				separators[i] = "---"
+			}
+			writeMarkdownRow(&b, separators)
+			for _, row := range table.Rows {
+				writeMarkdownRow(&b, row)
+			}
+		}
+	}
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+func writeMarkdownRow(b *strings.Builder, cells []string) {
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		escaped[i] = markdownEscape(cell)
+	}
+	fmt.Fprintf(b, "| %s |\n", strings.Join(escaped, " | "))
+}
+
+func markdownEscape(text string) string {
+	return strings.NewReplacer("|", `\|`, "*", `\*`, "_", `\_`).Replace(text)
+}
+
+func writeReportHTML(out io.Writer, doc report) error {
+	tmpl, err := template.ParseFS(webFiles, "web/report.html")
+	if err != nil {
+		return fmt.Errorf("parse report template: %w", err)
+	}
+	return tmpl.Execute(out, doc)
+}
@@ -0,0 +1,37 @@
+package worldclass
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRunReportIsReadOnlyWithoutSync(t *testing.T) {
+	var requests atomic.Int32
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer site.Close()
+	cfg := &Config{BaseURL: site.URL, Timezone: "UTC", StateDir: t.TempDir(), Profiles: []Profile{{Name: "ana", Credentials: Credentials{Email: "ana@example.com", Password: "secret"}, Clubs: []Club{{ID: "454", Name: "Park Lake"}}}}}
+
+	var out bytes.Buffer
+	if err := RunReport(cfg, ReportOptions{Out: &out, Logger: discardLogger()}); err != nil {
+		t.Fatalf("RunReport: %v", err)
+	}
+	if out.Len() == 0 {
+		t.Error("RunReport wrote no report")
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("RunReport sent %d requests to the site without --sync", n)
+	}
+
+	if err := RunReport(cfg, ReportOptions{Out: &out, Sync: true, Logger: discardLogger()}); err != nil {
+		t.Fatalf("RunReport with Sync: %v", err)
+	}
+	if requests.Load() == 0 {
+		t.Error("RunReport with Sync did not contact the site")
+	}
+}
@@ -0,0 +1,169 @@
+package worldclass
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// BookingRules limits how many classes get booked and how close together they may be.
+type BookingRules struct {
+	MaxPerDay  int  `yaml:"max_per_day"`
+	MaxPerWeek int  `yaml:"max_per_week"`
+	NoOverlap  bool `yaml:"no_overlap"`
+	// MinTravelGap is the minimum time between classes at different clubs on the same day (e.g. "45m").
+	MinTravelGap string `yaml:"min_travel_gap"`
+}
+
+func (r BookingRules) validate() error {
+	if r.MaxPerDay < 0 || r.MaxPerWeek < 0 {
+		return fmt.Errorf("booking limits cannot be negative")
+	}
+	if r.MinTravelGap != "" {
+		if _, err := time.ParseDuration(r.MinTravelGap); err != nil {
+			return fmt.Errorf("invalid min_travel_gap: %w", err)
+		}
+	}
+	return nil
+}
+
+// checkBookingRules evaluates the global and per-club rules for candidate against the classes already booked
+// in the fetched schedule. Day labels are resolved to dates with the year closest to reference. It returns an
+// empty string when booking is allowed, or the reason it is blocked.
+func checkBookingRules(global BookingRules, perClub map[string]BookingRules, classes []Class, candidate Class, reference time.Time) string {
+	if reason := evaluateRules(global, "", classes, candidate, reference); reason != "" {
+		return reason
+	}
+	if rules, ok := perClub[candidate.ClubName]; ok {
+		return evaluateRules(rules, candidate.ClubName, classes, candidate, reference)
+	}
+	return ""
+}
+
+// evaluateRules applies one rule set; a non-empty club limits the counted bookings to that club.
+func evaluateRules(rules BookingRules, club string, classes []Class, candidate Class, reference time.Time) string {
+	scope := "overall"
+	if club != "" {
+		scope = "at " + club
+	}
+
+	candidateStart, candidateEnd, rangeErr := parseTimeRange(candidate.Time)
+	var travelGap time.Duration
+	if rules.MinTravelGap != "" {
+		travelGap, _ = time.ParseDuration(rules.MinTravelGap)
+	}
+
+	perDay, perWeek := 0, 0
+	for _, booked := range classes {
+		if !booked.Booked || (booked.ClubID == candidate.ClubID && booked.ClassID == candidate.ClassID) {
+			continue
+		}
+		if club != "" && booked.ClubName != club {
+			continue
+		}
+
+		if !sameWeek(booked.Day, candidate.Day, reference) {
+			continue
+		}
+		perWeek++
+		if !sameDay(booked.Day, candidate.Day, reference) {
+			continue
+		}
+		perDay++
+
+		if rangeErr != nil {
+			continue
+		}
+		bookedStart, bookedEnd, err := parseTimeRange(booked.Time)
+		if err != nil {
+			continue
+		}
+
+		if rules.NoOverlap && candidateStart < bookedEnd && bookedStart < candidateEnd {
+			return fmt.Sprintf("overlaps booked %s %s at %s", booked.Title, booked.Time, booked.ClubName)
+		}
+
+		if travelGap > 0 && booked.ClubID != candidate.ClubID {
+			gap := candidateStart - bookedEnd
+			if bookedStart > candidateStart {
+				gap = bookedStart - candidateEnd
+			}
+			if time.Duration(gap)*time.Minute < travelGap {
+				return fmt.Sprintf("less than %s travel time from booked %s %s at %s", travelGap, booked.Title, booked.Time, booked.ClubName)
+			}
+		}
+	}
+
+	if rules.MaxPerDay > 0 && perDay >= rules.MaxPerDay {
+		return fmt.Sprintf("daily limit of %d bookings %s reached", rules.MaxPerDay, scope)
+	}
+	if rules.MaxPerWeek > 0 && perWeek >= rules.MaxPerWeek {
+		return fmt.Sprintf("weekly limit of %d bookings %s reached", rules.MaxPerWeek, scope)
+	}
+
+	return ""
+}
+
+// markBooked flags the matching class as booked so later rule checks in the same run account for it.
+func markBooked(classes []Class, classInfo Class) {
+	for i := range classes {
+		if classes[i].ClubID == classInfo.ClubID && classes[i].ClassID == classInfo.ClassID {
+			classes[i].Booked = true
+			classes[i].Bookable = false
+		}
+	}
+}
+
+// parseTimeRange parses "HH:MM - HH:MM" into minutes after midnight; a missing end assumes a one hour class.
+func parseTimeRange(raw string) (int, int, error) {
+	hour, minute, err := parseStartTime(raw)
+	if err != nil {
+		return 0, 0, err
+	}
+	start := hour*60 + minute
+
+	parts := strings.Split(raw, "-")
+	if len(parts) < 2 {
+		return start, start + 60, nil
+	}
+
+	endHour, endMinute, err := parseStartTime(parts[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	end := endHour*60 + endMinute
+	if end <= start {
+		end = start + 60
+	}
+
+	return start, end, nil
+}
+
+// sameDay compares two day labels by their dates ("Luni 20.10"), falling back to the weekday when a label
+// has no date.
+func sameDay(a, b string, reference time.Time) bool {
+	if strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b)) {
+		return true
+	}
+	dateA, okA := labelDate(a, reference)
+	dateB, okB := labelDate(b, reference)
+	if okA && okB {
+		return dateA.Equal(dateB)
+	}
+	dayA, okA := dayLabelWeekday(a)
+	dayB, okB := dayLabelWeekday(b)
+	return okA && okB && dayA == dayB
+}
+
+// sameWeek reports whether two day labels fall into the same ISO week. Labels without a date count as the
+// same week, since a fetched schedule covers a single week.
+func sameWeek(a, b string, reference time.Time) bool {
+	dateA, okA := labelDate(a, reference)
+	dateB, okB := labelDate(b, reference)
+	if !okA || !okB {
+		return true
+	}
+	yearA, weekA := dateA.ISOWeek()
+	yearB, weekB := dateB.ISOWeek()
+	return yearA == yearB && weekA == weekB
+}
@@ -0,0 +1,70 @@
+package worldclass
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheckBookingRules(t *testing.T) {
+	reference := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	candidate := Class{ClubID: "454", ClubName: "Park Lake", Day: "Luni 26.10", Time: "18:00 - 19:00", Title: "PILATES", ClassID: "9"}
+	booked := func(id, day, clock string) Class {
+		return Class{ClubID: "454", ClubName: "Park Lake", Day: day, Time: clock, Title: "YOGA", ClassID: id, Booked: true}
+	}
+
+	tests := []struct {
+		name    string
+		rules   BookingRules
+		classes []Class
+		blocked bool
+	}{
+		{
+			name:    "daily limit ignores the same weekday a week earlier",
+			rules:   BookingRules{MaxPerDay: 1},
+			classes: []Class{booked("1", "Luni 19.10", "18:00 - 19:00")},
+		},
+		{
+			name:    "daily limit counts the same date",
+			rules:   BookingRules{MaxPerDay: 1},
+			classes: []Class{booked("1", "Luni 26.10", "08:00 - 09:00")},
+			blocked: true,
+		},
+		{
+			name:    "weekly limit ignores other weeks",
+			rules:   BookingRules{MaxPerWeek: 2},
+			classes: []Class{booked("1", "Vineri 23.10", "18:00 - 19:00"), booked("2", "Luni 02.11", "18:00 - 19:00")},
+		},
+		{
+			name:    "weekly limit counts the candidate's week",
+			rules:   BookingRules{MaxPerWeek: 2},
+			classes: []Class{booked("1", "Marti 27.10", "18:00 - 19:00"), booked("2", "Duminica 01.11", "10:00 - 11:00")},
+			blocked: true,
+		},
+		{
+			name:    "overlap on another date is allowed",
+			rules:   BookingRules{NoOverlap: true},
+			classes: []Class{booked("1", "Luni 19.10", "18:30 - 19:30")},
+		},
+		{
+			name:    "overlap on the same date",
+			rules:   BookingRules{NoOverlap: true},
+			classes: []Class{booked("1", "Luni 26.10", "18:30 - 19:30")},
+			blocked: true,
+		},
+		{
+			name:    "labels without dates compare weekdays",
+			rules:   BookingRules{MaxPerDay: 1},
+			classes: []Class{booked("1", "Luni", "08:00 - 09:00")},
+			blocked: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := checkBookingRules(tt.rules, nil, tt.classes, candidate, reference)
+			if (reason != "") != tt.blocked {
+				t.Errorf("checkBookingRules() = %q, want blocked %v", reason, tt.blocked)
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package worldclass
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	wc "github.com/tatulea/worldclass-scheduler/pkg/worldclass"
+)
+
+// Clock abstracts the passage of time so the scheduling loop can be driven by a fake clock.
+type Clock interface {
+	Now() time.Time
+	// Sleep waits for d, returning ctx.Err() early when ctx is cancelled.
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+// Fetcher retrieves the class schedule of the given clubs for an account.
+type Fetcher interface {
+	FetchClasses(ctx context.Context, creds Credentials, clubs []Club) ([]Class, error)
+}
+
+// Booker opens authenticated sessions that can book and cancel classes.
+type Booker interface {
+	Login(ctx context.Context, creds Credentials) (BookingSession, error)
+}
+
+// BookingSession books and cancels classes on behalf of a logged in account.
+type BookingSession interface {
+	Book(ctx context.Context, clubID, classID string) error
+	// Cancel follows the cancel link scraped with a booked class (Class.CancelURL).
+	Cancel(ctx context.Context, cancelURL string) error
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func clockOrDefault(clock Clock) Clock {
+	if clock == nil {
+		return realClock{}
+	}
+	return clock
+}
+
+// clientBooker adapts *wc.Client to Booker.
+type clientBooker struct {
+	client *wc.Client
+}
+
+func (b clientBooker) Login(ctx context.Context, creds Credentials) (BookingSession, error) {
+	session, err := b.client.Login(ctx, creds)
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// Scheduler runs the booking loop of one account: it sleeps until each interest's booking window opens,
+// retries until the class is booked or the grace cutoff passes, then moves on to the next occurrence.
+type Scheduler struct {
+	acct     *account
+	clock    Clock
+	location *time.Location
+	// healthWeek is the ISO week the interest health check last ran for.
+	healthWeek string
+}
+
+func newScheduler(acct *account, location *time.Location) *Scheduler {
+	return &Scheduler{acct: acct, clock: acct.clock, location: location}
+}
+
+// Run loops until ctx is cancelled, which returns nil, or until the configured interests cannot be scheduled.
+func (s *Scheduler) Run(ctx context.Context) error {
+	acct := s.acct
+	if acct.hub != nil {
+		defer func() {
+			if r := recover(); r != nil {
+				acct.hub.Recover(r)
+				acct.hub.Flush(5 * time.Second)
+				panic(r)
+			}
+		}()
+	}
+
+	for {
+		if ctx.Err() != nil {
+			acct.state.setPhase(LoopPhaseStopped)
+			return nil
+		}
+
+		now := s.clock.Now().In(s.location)
+		handle, startTime, err := nextInterestOccurrence(acct.profile.Interests, s.location, now)
+		if err != nil {
+			if errors.Is(err, errNoInterests) {
+				acct.log.Info("no interests configured", "sleep", idleLoopDelay)
+				acct.state.setPhase(LoopPhaseIdle)
+				s.clock.Sleep(ctx, idleLoopDelay)
+				continue
+			}
+			reportLoopError(acct.hub, err, map[string]string{"phase": "next_interest"})
+			acct.state.setError(err)
+			acct.state.setPhase(LoopPhaseStopped)
+			return err
+		}
+
+		s.checkHealth(now)
+		wakeTime, err := s.waitForWindow(ctx, handle, startTime)
+		if err != nil {
+			continue
+		}
+		// The pause is checked once the window opens, so pauses set while the loop sleeps apply too.
+		if pause, paused := acct.pausedAt(startTime); paused {
+			s.skipPausedOccurrence(handle, startTime, pause)
+		} else {
+			if acct.store != nil && !acct.dryRun {
+				go s.sampleFill(ctx, handle, startTime)
+			}
+			if settled := s.bookWindow(ctx, handle, startTime, wakeTime); !settled {
+				continue
+			}
+		}
+
+		reference := startTime.Add(time.Second)
+		nextHandle, nextStart, err := nextInterestOccurrence(acct.profile.Interests, s.location, reference)
+		if err != nil {
+			if errors.Is(err, errNoInterests) {
+				acct.log.Info("no interests configured", "sleep", idleLoopDelay)
+				acct.state.setPhase(LoopPhaseIdle)
+				s.clock.Sleep(ctx, idleLoopDelay)
+			} else {
+				reportLoopError(acct.hub, err, map[string]string{"phase": "next_interest_after_success"})
+				acct.state.setError(err)
+				acct.state.setPhase(LoopPhaseStopped)
+				return err
+			}
+			continue
+		}
+
+		s.waitForWindow(ctx, nextHandle, nextStart)
+	}
+}
+
+// WakeTime returns when the loop wakes up for a class starting at startTime.
+func WakeTime(startTime time.Time) time.Time {
+	return startTime.Add(-bookingLeadTime).Add(-bookingEarlyBuffer)
+}
+
+// waitForWindow sleeps until the booking window of handle is about to open and returns the wake time, or
+// ctx.Err() when ctx is cancelled first.
+func (s *Scheduler) waitForWindow(ctx context.Context, handle *scheduledInterest, startTime time.Time) (time.Time, error) {
+	acct := s.acct
+	wakeTime := WakeTime(startTime)
+	attrs := interestAttrs(handle.Club, handle.Interest)
+	acct.state.setTarget(LoopPhaseSleeping, handle, startTime, wakeTime)
+
+	if sleepDuration := wakeTime.Sub(s.clock.Now()); sleepDuration > 0 {
+		acct.log.Info("next class scheduled", append(attrs, "phase", "sleep", "start", startTime.Format(time.RFC1123), "wake", wakeTime.Format(time.RFC1123))...)
+		return wakeTime, s.clock.Sleep(ctx, sleepDuration)
+	}
+
+	if s.clock.Now().Sub(wakeTime) < bookingEarlyBuffer {
+		acct.log.Info("reached booking buffer, polling until booking opens", append(attrs, "phase", "wake")...)
+	} else {
+		acct.log.Info("booking window already open, attempting immediately", append(attrs, "phase", "wake")...)
+	}
+	return wakeTime, nil
+}
+
+// bookWindow retries booking handle until it is booked, blocked by a rule or the grace cutoff passes.
+// It reports whether the occurrence was settled, in which case the loop advances to the next one. A cancelled
+// ctx abandons the window without recording it.
+func (s *Scheduler) bookWindow(ctx context.Context, handle *scheduledInterest, startTime, wakeTime time.Time) bool {
+	acct := s.acct
+	attrs := interestAttrs(handle.Club, handle.Interest)
+	checkIn := beginWindowCheckIn(acct, handle, wakeTime, s.location)
+	acct.resetFailureNotices()
+	deadline := handle.LastStart.Add(bookingGracePeriod)
+	acct.state.setTarget(LoopPhaseBooking, handle, startTime, wakeTime)
+	// last keeps the most recent result for the history record written when the window closes.
+	var (
+		last     interestResult
+		attempts int
+	)
+
+	for {
+		if s.clock.Now().After(deadline) {
+			checkIn.finish(sentry.CheckInStatusError)
+			acct.log.Warn("unable to book before cutoff; will retry next occurrence", append(attrs, "phase", "booking")...)
+			acct.state.setResult("missed "+interestLabel(handle.Interest)+" at "+handle.Club, s.clock.Now())
+			acct.recordWindow(handle, startTime, windowMissed, "", last.Class)
+			acct.notify(EventWindowMissed, Class{
+				ClubName: handle.Club,
+				Day:      startTime.Format("Monday 02 Jan"),
+				Time:     startTime.Format("15:04"),
+				Title:    handle.Interest.Title,
+			}, "unable to book before the class started")
+			return false
+		}
+
+		attemptCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		if attempts > 0 {
+			// The first attempt keeps the schedule snapshot current; retries refetch it every few seconds.
+			attemptCtx = withoutSnapshot(attemptCtx)
+		}
+		attempts++
+		results, err := scheduleInterests(attemptCtx, acct, map[string][]ClassInterest{handle.Club: {handle.Interest}}, startTime.Add(-bookingLeadTime))
+		cancel()
+		if err != nil {
+			if ctx.Err() != nil {
+				checkIn.finish(sentry.CheckInStatusError)
+				return false
+			}
+			acct.log.Error("scheduling attempt failed", append(attrs, "phase", "booking", "error", err)...)
+			acct.state.setError(err)
+			reportLoopError(acct.hub, err, map[string]string{
+				"phase": "booking",
+				"club":  handle.Club,
+				"title": handle.Interest.Title,
+			})
+			if s.clock.Sleep(ctx, bookingRetryDelay) != nil {
+				checkIn.finish(sentry.CheckInStatusError)
+				return false
+			}
+			continue
+		}
+
+		if res, ok := resultFor(handle, results); ok && res.Status != statusNoMatch {
+			last = res
+		}
+		if interestSatisfied(handle, results) {
+			checkIn.finish(sentry.CheckInStatusOK)
+			acct.state.setResult("booked "+interestLabel(handle.Interest)+" at "+handle.Club, s.clock.Now())
+			acct.recordWindow(handle, startTime, windowBooked, "", last.Class)
+			return true
+		}
+		if reason := interestBlocked(handle, results); reason != "" {
+			// A rule or a rolled-back group booking decided against this occurrence; move on instead of retrying
+			// until the cutoff.
+			acct.log.Info("not booking", append(attrs, "phase", "booking", "reason", reason)...)
+			checkIn.finish(sentry.CheckInStatusOK)
+			acct.state.setResult("skipped "+interestLabel(handle.Interest)+" at "+handle.Club+": "+reason, s.clock.Now())
+			acct.recordWindow(handle, startTime, windowSkipped, reason, last.Class)
+			return true
+		}
+
+		if s.clock.Sleep(ctx, bookingRetryDelay) != nil {
+			checkIn.finish(sentry.CheckInStatusError)
+			return false
+		}
+	}
+}
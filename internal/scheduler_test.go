@@ -0,0 +1,136 @@
+package worldclass
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestWaitForWindow(t *testing.T) {
+	start := time.Date(2026, 10, 19, 18, 0, 0, 0, time.UTC)
+	wake := WakeTime(start)
+
+	tests := []struct {
+		name      string
+		now       time.Time
+		wantSleep []time.Duration
+	}{
+		{name: "window ahead", now: wake.Add(-3 * time.Hour), wantSleep: []time.Duration{3 * time.Hour}},
+		{name: "inside the early buffer", now: wake.Add(30 * time.Second)},
+		{name: "window already open", now: wake.Add(time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: tt.now}
+			acct := newTestAccount(&fakeFetcher{}, &fakeBooker{})
+			acct.clock = clock
+			handle := &scheduledInterest{Club: "Park Lake", Interest: ClassInterest{Title: "PILATES"}, LastStart: start}
+
+			got, err := newScheduler(acct, time.UTC).waitForWindow(context.Background(), handle, start)
+			if err != nil {
+				t.Fatalf("waitForWindow: %v", err)
+			}
+			if !got.Equal(wake) {
+				t.Errorf("wake time = %v, want %v", got, wake)
+			}
+			if !slices.Equal(clock.sleeps, tt.wantSleep) {
+				t.Errorf("slept %v, want %v", clock.sleeps, tt.wantSleep)
+			}
+		})
+	}
+}
+
+func TestWaitForWindowStopsWithContext(t *testing.T) {
+	start := time.Date(2026, 10, 19, 18, 0, 0, 0, time.UTC)
+	acct := newTestAccount(&fakeFetcher{}, &fakeBooker{})
+	acct.clock = &fakeClock{now: start.Add(-48 * time.Hour)}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	handle := &scheduledInterest{Club: "Park Lake", Interest: ClassInterest{Title: "PILATES"}, LastStart: start}
+	if _, err := newScheduler(acct, time.UTC).waitForWindow(ctx, handle, start); err == nil {
+		t.Error("waitForWindow returned no error for a cancelled context")
+	}
+	if err := newScheduler(acct, time.UTC).Run(ctx); err != nil {
+		t.Errorf("Run with a cancelled context = %v, want nil", err)
+	}
+}
+
+func TestBookWindow(t *testing.T) {
+	start := time.Date(2026, 10, 19, 18, 0, 0, 0, time.UTC)
+	wake := WakeTime(start)
+	interest := ClassInterest{Title: "PILATES", DayEnglish: "Monday", Time: "18:00 - 19:00"}
+	pilates := Class{ClubID: "454", ClubName: "Park Lake", Day: "Luni 19.10", Time: "18:00 - 19:00", Title: "PILATES", ClassID: "1"}
+	bookable := pilates
+	bookable.Bookable = true
+	overlapping := Class{ClubID: "454", ClubName: "Park Lake", Day: "Luni 19.10", Time: "17:30 - 18:30", Title: "SPINNING", ClassID: "2", Booked: true}
+
+	tests := []struct {
+		name        string
+		classes     []Class
+		rules       BookingRules
+		wantSettled bool
+		wantBooked  []string
+		wantEvents  []EventKind
+		// wantPastDeadline expects the window to have been retried until the grace cutoff.
+		wantPastDeadline bool
+	}{
+		{
+			name:        "booked",
+			classes:     []Class{bookable},
+			wantSettled: true,
+			wantBooked:  []string{"1"},
+			wantEvents:  []EventKind{EventBooked},
+		},
+		{
+			name:             "missed",
+			classes:          []Class{pilates},
+			wantEvents:       []EventKind{EventWindowMissed},
+			wantPastDeadline: true,
+		},
+		{
+			name:        "blocked by a rule",
+			classes:     []Class{overlapping, bookable},
+			rules:       BookingRules{NoOverlap: true},
+			wantSettled: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: wake}
+			fetcher := &fakeFetcher{classes: tt.classes}
+			booker := &fakeBooker{}
+			notifier := &recordingNotifier{}
+			acct := newTestAccount(fetcher, booker)
+			acct.clock = clock
+			acct.notifier = notifier
+			acct.rules = tt.rules
+			handle := &scheduledInterest{Club: "Park Lake", Interest: interest, LastStart: start}
+
+			settled := newScheduler(acct, time.UTC).bookWindow(context.Background(), handle, start, wake)
+			acct.flushNotifications()
+
+			if settled != tt.wantSettled {
+				t.Errorf("settled = %v, want %v", settled, tt.wantSettled)
+			}
+			if got := booker.bookedIDs(); !slices.Equal(got, tt.wantBooked) {
+				t.Errorf("booked %v, want %v", got, tt.wantBooked)
+			}
+			if got := notifier.events(); !slices.Equal(got, tt.wantEvents) {
+				t.Errorf("events = %v, want %v", got, tt.wantEvents)
+			}
+			deadline := start.Add(bookingGracePeriod)
+			if pastDeadline := clock.Now().After(deadline); pastDeadline != tt.wantPastDeadline {
+				t.Errorf("clock stopped at %v, deadline %v", clock.Now(), deadline)
+			}
+			if tt.wantPastDeadline {
+				if want := int(deadline.Sub(wake)/bookingRetryDelay) + 1; fetcher.calls != want {
+					t.Errorf("fetched %d times, want one attempt every %v until the cutoff (%d)", fetcher.calls, bookingRetryDelay, want)
+				}
+			}
+		})
+	}
+}
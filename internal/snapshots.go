@@ -0,0 +1,532 @@
+package worldclass
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	wc "github.com/tatulea/worldclass-scheduler/pkg/worldclass"
+)
+
+const (
+	snapshotsDir = "snapshots"
+	// snapshotKeep is how many schedule snapshots are kept per profile.
+	snapshotKeep = 50
+	// snapshotLayout names snapshot files so that they sort chronologically.
+	snapshotLayout = "20060102T150405Z"
+)
+
+// Schedule change kinds reported by diffSchedules.
+const (
+	changeAdded     = "added"
+	changeRemoved   = "removed"
+	changeMoved     = "moved"
+	changeRetrained = "retrained"
+)
+
+// scheduleSnapshot is the schedule a profile saw at one point in time, without booking state.
+type scheduleSnapshot struct {
+	Profile string          `json:"profile"`
+	At      time.Time       `json:"at"`
+	Classes []snapshotClass `json:"classes"`
+}
+
+type snapshotClass struct {
+	Club     string `json:"club"`
+	ClubID   string `json:"club_id"`
+	Day      string `json:"day"`
+	Time     string `json:"time"`
+	Title    string `json:"title"`
+	Trainer  string `json:"trainer,omitempty"`
+	Room     string `json:"room,omitempty"`
+	Category string `json:"category,omitempty"`
+	ClassID  string `json:"class_id,omitempty"`
+}
+
+func snapshotClassOf(classInfo Class) snapshotClass {
+	return snapshotClass{
+		Club:     classInfo.ClubName,
+		ClubID:   classInfo.ClubID,
+		Day:      classInfo.Day,
+		Time:     classInfo.Time,
+		Title:    classInfo.Title,
+		Trainer:  classInfo.Trainer,
+		Room:     classInfo.Room,
+		Category: classInfo.Category,
+		ClassID:  classInfo.ClassID,
+	}
+}
+
+func (c snapshotClass) class() Class {
+	return Class{
+		ClubID:   c.ClubID,
+		ClubName: c.Club,
+		Day:      c.Day,
+		Time:     c.Time,
+		Title:    c.Title,
+		Trainer:  c.Trainer,
+		Room:     c.Room,
+		Category: c.Category,
+		ClassID:  c.ClassID,
+	}
+}
+
+// slot identifies a class across weeks, when its date and class ID change: club, weekday and title.
+func (c snapshotClass) slot() string {
+	day := strings.ToLower(strings.TrimSpace(c.Day))
+	if weekday, ok := dayLabelWeekday(c.Day); ok {
+		day = weekday.String()
+	} else if fields := strings.Fields(day); len(fields) > 0 {
+		day = fields[0]
+	}
+	return c.Club + "\t" + day + "\t" + strings.ToUpper(strings.TrimSpace(c.Title))
+}
+
+// scheduleChange is one difference between two snapshots; Old is nil for added classes and New for removed ones.
+type scheduleChange struct {
+	Kind string
+	Old  *snapshotClass
+	New  *snapshotClass
+}
+
+func (c scheduleChange) class() snapshotClass {
+	if c.New != nil {
+		return *c.New
+	}
+	return *c.Old
+}
+
+func (c scheduleChange) details() string {
+	switch c.Kind {
+	case changeMoved:
+		details := fmt.Sprintf("%s -> %s", c.Old.Time, c.New.Time)
+		if c.Old.Trainer != c.New.Trainer {
+			details += fmt.Sprintf(", trainer %s -> %s", c.Old.Trainer, c.New.Trainer)
+		}
+		return details
+	case changeRetrained:
+		return fmt.Sprintf("trainer %s -> %s", c.Old.Trainer, c.New.Trainer)
+	default:
+		class := c.class()
+		return strings.TrimSpace(class.Time + " " + class.Trainer)
+	}
+}
+
+// snapshotFetcher saves what the wrapped fetcher returns for the account's own credentials as a schedule snapshot.
+// Only clubs fetched across every category are saved: a fetch narrowed to some categories shows part of the
+// club's schedule and would make the rest look removed.
+type snapshotFetcher struct {
+	next Fetcher
+	acct *account
+}
+
+type skipSnapshotKey struct{}
+
+// withoutSnapshot marks ctx so that fetches made with it are not saved as snapshots, for callers that refetch
+// the same schedule every few seconds, such as booking retries.
+func withoutSnapshot(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipSnapshotKey{}, true)
+}
+
+func (f snapshotFetcher) FetchClasses(ctx context.Context, creds Credentials, clubs []Club) ([]Class, error) {
+	classes, err := f.next.FetchClasses(ctx, creds, clubs)
+	if err != nil || f.acct.store == nil || creds != f.acct.profile.Credentials || ctx.Value(skipSnapshotKey{}) != nil {
+		return classes, err
+	}
+
+	complete := slices.DeleteFunc(slices.Clone(clubs), func(club Club) bool {
+		return len(club.Groups) > 0 && !slices.ContainsFunc(club.Groups, func(g Group) bool { return g.ID == wc.AllGroupsID })
+	})
+	if len(complete) == 0 {
+		return classes, nil
+	}
+	snapshot := slices.DeleteFunc(slices.Clone(classes), func(classInfo Class) bool {
+		return !slices.ContainsFunc(complete, func(club Club) bool { return club.ID == classInfo.ClubID })
+	})
+
+	previous, current, changed, saveErr := f.acct.store.saveSnapshot(f.acct.profile.Name, complete, snapshot, f.acct.clock.Now())
+	if saveErr != nil {
+		f.acct.log.Warn("could not save schedule snapshot", "error", saveErr)
+		return classes, nil
+	}
+	if changed && previous != nil && f.acct.watchSchedule {
+		f.acct.scheduleChanged(diffSchedules(previous.Classes, current.Classes), previous.Classes, current.Classes)
+	}
+	return classes, nil
+}
+
+// saveSnapshot merges the classes fetched for clubs into the profile's latest snapshot and stores the result
+// when it differs. It returns the latest snapshot before the fetch (nil for the first one) and the merged one.
+// A class fetched without a category keeps the one an earlier snapshot recorded, since only fetches narrowed
+// to a category report it.
+func (s *stateStore) saveSnapshot(profile string, clubs []Club, classes []Class, now time.Time) (*scheduleSnapshot, scheduleSnapshot, bool, error) {
+	s.snapshotMu.Lock()
+	defer s.snapshotMu.Unlock()
+
+	previous, err := s.latestSnapshot(profile)
+	if err != nil {
+		return nil, scheduleSnapshot{}, false, err
+	}
+
+	fetched := make(map[string]bool, len(clubs))
+	for _, club := range clubs {
+		fetched[club.ID] = true
+	}
+	categories := make(map[string]string)
+	current := scheduleSnapshot{Profile: profile, At: now.UTC()}
+	if previous != nil {
+		for _, class := range previous.Classes {
+			if !fetched[class.ClubID] {
+				current.Classes = append(current.Classes, class)
+			} else if class.Category != "" {
+				categories[class.ClubID+"\t"+class.ClassID] = class.Category
+			}
+		}
+	}
+	for _, classInfo := range classes {
+		class := snapshotClassOf(classInfo)
+		if class.Category == "" && class.ClassID != "" {
+			class.Category = categories[class.ClubID+"\t"+class.ClassID]
+		}
+		current.Classes = append(current.Classes, class)
+	}
+	sort.SliceStable(current.Classes, func(i, j int) bool { return current.Classes[i].Club < current.Classes[j].Club })
+
+	if previous != nil && slices.Equal(previous.Classes, current.Classes) {
+		return previous, current, false, nil
+	}
+
+	data, err := json.MarshalIndent(current, "", "  ")
+	if err != nil {
+		return nil, scheduleSnapshot{}, false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir := s.snapshotDir(profile)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, scheduleSnapshot{}, false, fmt.Errorf("create snapshot dir: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, current.At.Format(snapshotLayout)+".json"), data, 0o644); err != nil {
+		return nil, scheduleSnapshot{}, false, err
+	}
+
+	names, err := snapshotNames(dir)
+	if err != nil {
+		return nil, scheduleSnapshot{}, false, err
+	}
+	for len(names) > snapshotKeep {
+		if err := os.Remove(filepath.Join(dir, names[0])); err != nil {
+			return nil, scheduleSnapshot{}, false, err
+		}
+		names = names[1:]
+	}
+	return previous, current, true, nil
+}
+
+// latestSnapshot returns the profile's newest snapshot, or nil when none was saved yet.
+func (s *stateStore) latestSnapshot(profile string) (*scheduleSnapshot, error) {
+	snapshots, err := s.recentSnapshots(profile, 1)
+	if err != nil || len(snapshots) == 0 {
+		return nil, err
+	}
+	return &snapshots[0], nil
+}
+
+// recentSnapshots returns up to count of the profile's newest snapshots, newest first.
+func (s *stateStore) recentSnapshots(profile string, count int) ([]scheduleSnapshot, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir := s.snapshotDir(profile)
+	names, err := snapshotNames(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []scheduleSnapshot
+	for i := len(names) - 1; i >= 0 && len(snapshots) < count; i-- {
+		data, err := os.ReadFile(filepath.Join(dir, names[i]))
+		if err != nil {
+			return nil, err
+		}
+		var snapshot scheduleSnapshot
+		if err := json.Unmarshal(data, &snapshot); err != nil {
+			return nil, fmt.Errorf("snapshot %s: %w", names[i], err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, nil
+}
+
+func (s *stateStore) snapshotDir(profile string) string {
+	return filepath.Join(s.dir, snapshotsDir, url.PathEscape(profile))
+}
+
+// snapshotNames lists the snapshot files in dir, oldest first; a missing dir has none.
+func snapshotNames(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// diffSchedules compares two snapshots club by club, skipping clubs missing from either side. Classes are paired
+// by slot: the same start time with another trainer is retrained, a different start time is moved, and unpaired
+// classes are added or removed.
+func diffSchedules(old, current []snapshotClass) []scheduleChange {
+	oldClubs, newClubs := make(map[string]bool), make(map[string]bool)
+	for _, class := range old {
+		oldClubs[class.Club] = true
+	}
+	for _, class := range current {
+		newClubs[class.Club] = true
+	}
+
+	oldSlots, newSlots := make(map[string][]snapshotClass), make(map[string][]snapshotClass)
+	var order []string
+	for _, class := range old {
+		if newClubs[class.Club] {
+			if _, ok := oldSlots[class.slot()]; !ok {
+				order = append(order, class.slot())
+			}
+			oldSlots[class.slot()] = append(oldSlots[class.slot()], class)
+		}
+	}
+	for _, class := range current {
+		if oldClubs[class.Club] {
+			if _, ok := oldSlots[class.slot()]; !ok {
+				if _, ok := newSlots[class.slot()]; !ok {
+					order = append(order, class.slot())
+				}
+			}
+			newSlots[class.slot()] = append(newSlots[class.slot()], class)
+		}
+	}
+
+	var changes []scheduleChange
+	for _, slot := range order {
+		changes = append(changes, diffSlot(oldSlots[slot], newSlots[slot])...)
+	}
+	return changes
+}
+
+func diffSlot(old, current []snapshotClass) []scheduleChange {
+	var changes []scheduleChange
+	pair := func(match func(a, b snapshotClass) bool) {
+		for i := 0; i < len(old); i++ {
+			for j := 0; j < len(current); j++ {
+				if !match(old[i], current[j]) {
+					continue
+				}
+				before, after := old[i], current[j]
+				switch {
+				case before.Time != after.Time:
+					changes = append(changes, scheduleChange{Kind: changeMoved, Old: &before, New: &after})
+				case before.Trainer != after.Trainer:
+					changes = append(changes, scheduleChange{Kind: changeRetrained, Old: &before, New: &after})
+				}
+				old = slices.Delete(old, i, i+1)
+				current = slices.Delete(current, j, j+1)
+				i--
+				break
+			}
+		}
+	}
+
+	pair(func(a, b snapshotClass) bool { return a.Time == b.Time })
+	pair(func(a, b snapshotClass) bool { return a.Trainer == b.Trainer })
+	pair(func(a, b snapshotClass) bool { return true })
+
+	for _, class := range old {
+		changes = append(changes, scheduleChange{Kind: changeRemoved, Old: &class})
+	}
+	for _, class := range current {
+		changes = append(changes, scheduleChange{Kind: changeAdded, New: &class})
+	}
+	return changes
+}
+
+// interestWarning is a schedule change that affects one of the profile's interests.
+type interestWarning struct {
+	Event    EventKind
+	Club     string
+	Interest ClassInterest
+	Class    Class
+	Message  string
+}
+
+// interestWarnings reports interests whose matching class moved to another time and interests that matched a
+// class of the previous schedule but none of the current one, without reporting the same interest twice.
+// Interests that never matched are left to the health check, which alerts once they stay unmatched for a
+// while. Interests with no active date in the week of now are left out.
+func interestWarnings(interests map[string][]ClassInterest, changes []scheduleChange, previous, current []snapshotClass, now time.Time) []interestWarning {
+	weekStart := periodStart(PeriodWeek, now)
+	var warnings []interestWarning
+	for _, clubName := range sortedKeys(interests) {
+		if !slices.ContainsFunc(current, func(c snapshotClass) bool { return c.Club == clubName }) {
+			continue
+		}
+		for _, interest := range interests[clubName] {
+			if !interest.dueWithin(weekStart, weekStart.AddDate(0, 0, 7)) {
+				continue
+			}
+			moved := false
+			for _, change := range changes {
+				if change.Kind != changeMoved || change.Old.Club != clubName || !interestMatches(change.Old.class(), interest, now, nil) {
+					continue
+				}
+				moved = true
+				warnings = append(warnings, interestWarning{
+					Event:    EventClassMoved,
+					Club:     clubName,
+					Interest: interest,
+					Class:    change.New.class(),
+					Message:  fmt.Sprintf("%s moved from %s to %s", change.New.Title, change.Old.Time, change.New.Time),
+				})
+			}
+			if moved {
+				continue
+			}
+
+			matches := func(c snapshotClass) bool {
+				return c.Club == clubName && interestMatches(c.class(), interest, now, nil)
+			}
+			if slices.ContainsFunc(previous, matches) && !slices.ContainsFunc(current, matches) {
+				warnings = append(warnings, interestWarning{
+					Event:    EventInterestUnmatched,
+					Club:     clubName,
+					Interest: interest,
+					Class:    Class{ClubName: clubName, Day: interestDay(interest), Time: interest.Time, Title: interestLabel(interest)},
+					Message:  fmt.Sprintf("interest %s (%s) no longer matches any class", interestLabel(interest), strings.TrimSpace(interestDay(interest)+" "+interest.Time)),
+				})
+			}
+		}
+	}
+	return warnings
+}
+
+// scheduleChanged logs the differences found by the loop's fetches and notifies about affected interests.
+func (a *account) scheduleChanged(changes []scheduleChange, previous, current []snapshotClass) {
+	for _, change := range changes {
+		a.log.Info("schedule changed", append(classAttrs(change.class().class()), "change", change.Kind, "details", change.details())...)
+	}
+	for _, warning := range interestWarnings(a.profile.Interests, changes, previous, current, a.clock.Now().In(a.location)) {
+		a.log.Warn("schedule change affects interest", append(interestAttrs(warning.Club, warning.Interest), "event", warning.Event, "message", warning.Message)...)
+		a.notify(warning.Event, warning.Class, "%s", warning.Message)
+	}
+}
+
+// DiffOptions controls the behavior of RunDiff.
+type DiffOptions struct {
+	Profile string
+	// Offline compares the two latest snapshots instead of the live schedule with the latest snapshot.
+	Offline bool
+	Out     io.Writer
+}
+
+// RunDiff prints how the schedule changed since the last snapshot, and the interests the change affects.
+func RunDiff(cfg *Config, opts DiffOptions) error {
+	if cfg == nil {
+		return fmt.Errorf("configuration is required")
+	}
+
+	profiles, err := cfg.SelectProfiles(opts.Profile)
+	if err != nil {
+		return err
+	}
+
+	out := outputOrStdout(opts.Out)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	var warnings []interestWarning
+	compared, changed := false, false
+	for _, profile := range profiles {
+		acct, err := newAccount(cfg, profile, nil, nil)
+		if err != nil {
+			return err
+		}
+
+		var old, current *scheduleSnapshot
+		if opts.Offline {
+			snapshots, err := acct.store.recentSnapshots(profile.Name, 2)
+			if err != nil {
+				return err
+			}
+			if len(snapshots) == 2 {
+				current, old = &snapshots[0], &snapshots[1]
+			}
+		} else {
+			old, err = acct.store.latestSnapshot(profile.Name)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			_, err = acct.fetcher.FetchClasses(ctx, profile.Credentials, profile.Clubs)
+			cancel()
+			if err != nil {
+				return fmt.Errorf("profile %s: %w", profile.Name, err)
+			}
+			current, err = acct.store.latestSnapshot(profile.Name)
+			if err != nil {
+				return err
+			}
+		}
+		if old == nil || current == nil {
+			fmt.Fprintf(out, "%s: no earlier snapshot to compare with\n", profile.Name)
+			continue
+		}
+
+		compared = true
+		var changes []scheduleChange
+		if !old.At.Equal(current.At) {
+			changes = diffSchedules(old.Classes, current.Classes)
+		}
+		if len(changes) > 0 && !changed {
+			fmt.Fprintln(w, "PROFILE\tSINCE\tCLUB\tCHANGE\tDAY\tCLASS\tDETAILS")
+			changed = true
+		}
+		for _, change := range changes {
+			class := change.class()
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", profile.Name, old.At.Local().Format("2006-01-02 15:04"), class.Club, change.Kind, class.Day, class.Title, change.details())
+		}
+		warnings = append(warnings, interestWarnings(profile.Interests, changes, old.Classes, current.Classes, current.At.In(acct.location))...)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if compared && !changed {
+		fmt.Fprintln(out, "no schedule changes")
+	}
+	for _, warning := range warnings {
+		fmt.Fprintf(out, "warning: %s: %s\n", warning.Club, warning.Message)
+	}
+	return nil
+}
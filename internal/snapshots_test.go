@@ -0,0 +1,103 @@
+package worldclass
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	wc "github.com/tatulea/worldclass-scheduler/pkg/worldclass"
+)
+
+func TestSnapshotFetcherSavesCompleteSchedules(t *testing.T) {
+	pilates := Class{ClubID: "454", ClubName: "Park Lake", Day: "Luni 19.10", Time: "18:00 - 19:00", Title: "PILATES", ClassID: "1"}
+	spinning := Class{ClubID: "454", ClubName: "Park Lake", Day: "Luni 19.10", Time: "19:00 - 20:00", Title: "SPINNING", ClassID: "2"}
+	categorised := pilates
+	categorised.Category = "Mind & Body"
+
+	all := []Club{{ID: "454", Name: "Park Lake"}}
+	narrowed := []Club{{ID: "454", Name: "Park Lake", Groups: []Group{{Name: "Mind & Body"}}}}
+	allAndNarrowed := []Club{{ID: "454", Name: "Park Lake", Groups: []Group{{ID: wc.AllGroupsID}, {Name: "Mind & Body"}}}}
+
+	fetches := []struct {
+		name      string
+		ctx       context.Context
+		clubs     []Club
+		classes   []Class
+		wantSaved int
+	}{
+		{name: "first full fetch", clubs: all, classes: []Class{pilates, spinning}, wantSaved: 1},
+		{name: "narrowed fetch", clubs: narrowed, classes: []Class{categorised}, wantSaved: 1},
+		{name: "full fetch with categories", clubs: allAndNarrowed, classes: []Class{categorised, spinning}, wantSaved: 2},
+		{name: "full fetch without categories", clubs: all, classes: []Class{pilates, spinning}, wantSaved: 2},
+		{name: "booking retry", ctx: withoutSnapshot(context.Background()), clubs: all, classes: []Class{pilates}, wantSaved: 2},
+	}
+
+	acct := newTestAccount(nil, &fakeBooker{})
+	acct.store = newStateStore(t.TempDir())
+	clock := &fakeClock{now: time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)}
+	acct.clock = clock
+	for _, fetch := range fetches {
+		// Snapshot files are named by the second they were taken in.
+		clock.Sleep(context.Background(), time.Minute)
+		ctx := fetch.ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		fetcher := snapshotFetcher{next: &fakeFetcher{classes: fetch.classes}, acct: acct}
+		if _, err := fetcher.FetchClasses(ctx, acct.profile.Credentials, fetch.clubs); err != nil {
+			t.Fatalf("%s: %v", fetch.name, err)
+		}
+		names, err := snapshotNames(acct.store.snapshotDir(acct.profile.Name))
+		if err != nil {
+			t.Fatalf("%s: %v", fetch.name, err)
+		}
+		if len(names) != fetch.wantSaved {
+			t.Errorf("%s: %d snapshots saved, want %d", fetch.name, len(names), fetch.wantSaved)
+		}
+	}
+
+	latest, err := acct.store.latestSnapshot(acct.profile.Name)
+	if err != nil || latest == nil {
+		t.Fatalf("latest snapshot: %v, %v", latest, err)
+	}
+	if len(latest.Classes) != 2 || latest.Classes[0].Category != "Mind & Body" {
+		t.Errorf("latest snapshot = %+v, want both classes with PILATES categorised", latest.Classes)
+	}
+}
+
+func TestInterestWarnings(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	pilates := snapshotClass{Club: "Park Lake", ClubID: "454", Day: "Luni 19.10", Time: "18:00 - 19:00", Title: "PILATES"}
+	moved := pilates
+	moved.Time = "19:00 - 20:00"
+	spinning := snapshotClass{Club: "Park Lake", ClubID: "454", Day: "Marti 20.10", Time: "18:00 - 19:00", Title: "SPINNING"}
+	retrained := spinning
+	retrained.Trainer = "Ioana"
+
+	tests := []struct {
+		name     string
+		interest ClassInterest
+		previous []snapshotClass
+		current  []snapshotClass
+		want     []EventKind
+	}{
+		{name: "matching class moved", interest: ClassInterest{Title: "PILATES"}, previous: []snapshotClass{pilates, spinning}, current: []snapshotClass{moved, spinning}, want: []EventKind{EventClassMoved}},
+		{name: "matching class removed", interest: ClassInterest{Title: "PILATES"}, previous: []snapshotClass{pilates, spinning}, current: []snapshotClass{spinning}, want: []EventKind{EventInterestUnmatched}},
+		{name: "never matched", interest: ClassInterest{Title: "YOGA"}, previous: []snapshotClass{pilates, spinning}, current: []snapshotClass{pilates, retrained}},
+		{name: "still matching", interest: ClassInterest{Title: "PILATES"}, previous: []snapshotClass{pilates, spinning}, current: []snapshotClass{pilates, retrained}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interests := map[string][]ClassInterest{"Park Lake": {tt.interest}}
+			var got []EventKind
+			for _, warning := range interestWarnings(interests, diffSchedules(tt.previous, tt.current), tt.previous, tt.current, now) {
+				got = append(got, warning.Event)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("warnings = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
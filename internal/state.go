@@ -0,0 +1,137 @@
+package worldclass
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// defaultStateDirName is created next to the config file when state_dir is not set.
+const defaultStateDirName = "worldclass-state"
+
+// stateStore keeps append-only JSON Lines files in the state directory. A nil *stateStore drops writes.
+type stateStore struct {
+	dir string
+	mu  sync.Mutex
+	// snapshotMu serialises saveSnapshot so concurrent fetches compare against the snapshot saved before them.
+	snapshotMu sync.Mutex
+}
+
+func newStateStore(dir string) *stateStore {
+	if dir == "" {
+		return nil
+	}
+	return &stateStore{dir: dir}
+}
+
+// append writes record as one JSON line to the named file, creating the state directory on first use.
+func (s *stateStore) append(name string, record any) error {
+	if s == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("create state dir: %w", err)
+	}
+	file, err := os.OpenFile(filepath.Join(s.dir, name), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// readRecords decodes every line of the named file; a missing file yields no records.
+func readRecords[T any](s *stateStore, name string) ([]T, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.Open(filepath.Join(s.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var records []T
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record T
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", name, line, err)
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// load decodes the named JSON document into v, leaving v untouched when the file does not exist yet.
+func (s *stateStore) load(name string, v any) error {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(filepath.Join(s.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// save replaces the named JSON document with v, writing a temporary file first so readers never see half of it.
+func (s *stateStore) save(name string, v any) error {
+	if s == nil {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("create state dir: %w", err)
+	}
+	path := filepath.Join(s.dir, name)
+	if err := os.WriteFile(path+".tmp", append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
@@ -0,0 +1,282 @@
+package worldclass
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	windowsFile = "windows.jsonl"
+	fillsFile   = "fills.jsonl"
+
+	// Fill sampling starts every minute after the window opens and backs off to every 30 minutes.
+	fillSampleInitial = time.Minute
+	fillSampleMax     = 30 * time.Minute
+)
+
+// Booking window outcomes stored in windowRecord.Outcome.
+const (
+	windowBooked  = "booked"
+	windowMissed  = "missed"
+	windowSkipped = "skipped"
+)
+
+// windowRecord is one booking window handled by the loop.
+type windowRecord struct {
+	Profile    string    `json:"profile"`
+	Club       string    `json:"club"`
+	Interest   string    `json:"interest"`
+	Title      string    `json:"title,omitempty"`
+	Trainer    string    `json:"trainer,omitempty"`
+	ClassID    string    `json:"class_id,omitempty"`
+	Start      time.Time `json:"start"`
+	WindowOpen time.Time `json:"window_open"`
+	Outcome    string    `json:"outcome"`
+	Reason     string    `json:"reason,omitempty"`
+	At         time.Time `json:"at"`
+}
+
+// fillRecord is one observation of how long a class stayed bookable after its window opened.
+type fillRecord struct {
+	Profile    string    `json:"profile,omitempty"`
+	Club       string    `json:"club"`
+	Title      string    `json:"title"`
+	Trainer    string    `json:"trainer,omitempty"`
+	ClassID    string    `json:"class_id,omitempty"`
+	Start      time.Time `json:"start"`
+	WindowOpen time.Time `json:"window_open"`
+	// FilledAt is when the class was first seen without places; nil means it still had places at the start,
+	// or, with Booked, that this account booked it and the site stopped showing its places.
+	FilledAt *time.Time `json:"filled_at,omitempty"`
+	// Booked marks a class this account booked: it had places at the open, and how long they lasted is unknown.
+	Booked bool `json:"booked,omitempty"`
+}
+
+// interestKey names an interest in the history so windows of the same interest group together.
+func interestKey(interest ClassInterest) string {
+	return interestLabel(interest) + " | " + interest.describe()
+}
+
+// recordWindow stores the outcome of a booking window; failures are logged and otherwise ignored.
+func (a *account) recordWindow(handle *scheduledInterest, start time.Time, outcome, reason string, classInfo Class) {
+	record := windowRecord{
+		Profile:    a.profile.Name,
+		Club:       handle.Club,
+		Interest:   interestKey(handle.Interest),
+		Title:      classInfo.Title,
+		Trainer:    classInfo.Trainer,
+		ClassID:    classInfo.ClassID,
+		Start:      start,
+		WindowOpen: start.Add(-bookingLeadTime),
+		Outcome:    outcome,
+		Reason:     reason,
+		At:         a.clock.Now(),
+	}
+	if err := a.store.append(windowsFile, record); err != nil {
+		a.log.Warn("could not record booking window", "error", err)
+	}
+}
+
+// resultFor returns the result produced for handle's interest.
+func resultFor(handle *scheduledInterest, results []interestResult) (interestResult, bool) {
+	for _, res := range results {
+		if res.ClubName == handle.Club && interestsEqual(res.Interest, handle.Interest) {
+			return res, true
+		}
+	}
+	return interestResult{}, false
+}
+
+// sampleFill polls the class behind handle from the moment its window opens, alongside the booking attempts,
+// until it has no places left or starts, and records when it was first seen full. A class this account books
+// is recorded as booked: it had places at the open, but the site no longer shows whether any are left.
+// Windows the loop reaches late are not sampled, since the class may have filled before the first look.
+func (s *Scheduler) sampleFill(ctx context.Context, handle *scheduledInterest, start time.Time) {
+	acct := s.acct
+	windowOpen := start.Add(-bookingLeadTime)
+	if wait := windowOpen.Sub(s.clock.Now()); wait > 0 {
+		if s.clock.Sleep(ctx, wait) != nil {
+			return
+		}
+	} else if -wait > fillSampleInitial {
+		return
+	}
+	interval := fillSampleInitial
+
+	for {
+		fetchCtx, cancel := context.WithTimeout(withoutSnapshot(ctx), 30*time.Second)
+		classes, err := acct.fetcher.FetchClasses(fetchCtx, acct.profile.Credentials, acct.scheduleClubs())
+		cancel()
+
+		now := s.clock.Now()
+		if err == nil {
+			classInfo, found := findMatchingClass(classes, handle.Club, handle.Interest, now)
+			switch {
+			case !found:
+				return
+			case classInfo.Booked:
+				s.recordFill(fillRecord{Club: handle.Club, Start: start, WindowOpen: windowOpen, Booked: true}, classInfo)
+				return
+			case !classInfo.Bookable:
+				s.recordFill(fillRecord{Club: handle.Club, Start: start, WindowOpen: windowOpen, FilledAt: &now}, classInfo)
+				return
+			}
+
+			if !now.Add(interval).Before(start) {
+				s.recordFill(fillRecord{Club: handle.Club, Start: start, WindowOpen: windowOpen}, classInfo)
+				return
+			}
+		} else {
+			acct.log.Debug("fill sample failed", append(interestAttrs(handle.Club, handle.Interest), "error", err)...)
+			if !now.Add(interval).Before(start) {
+				return
+			}
+		}
+
+		if s.clock.Sleep(ctx, interval) != nil {
+			return
+		}
+		interval = min(interval*2, fillSampleMax)
+	}
+}
+
+// recordFill completes record with the profile and classInfo's details and stores it.
+func (s *Scheduler) recordFill(record fillRecord, classInfo Class) {
+	record.Profile = s.acct.profile.Name
+	record.Title = classInfo.Title
+	record.Trainer = classInfo.Trainer
+	record.ClassID = classInfo.ClassID
+	if err := s.acct.store.append(fillsFile, record); err != nil {
+		s.acct.log.Warn("could not record fill sample", "error", err)
+	}
+}
+
+// StatsOptions controls the behavior of RunStats.
+type StatsOptions struct {
+	Profile string
+	Out     io.Writer
+}
+
+// RunStats reports median fill times per class, trainer and club, and the booking success rate per interest,
+// from the history recorded by the booking loop.
+func RunStats(cfg *Config, opts StatsOptions) error {
+	if cfg == nil {
+		return fmt.Errorf("configuration is required")
+	}
+	if _, err := cfg.SelectProfiles(opts.Profile); err != nil {
+		return err
+	}
+
+	store := newStateStore(cfg.StateDir)
+	fills, err := readRecords[fillRecord](store, fillsFile)
+	if err != nil {
+		return err
+	}
+	windows, err := readRecords[windowRecord](store, windowsFile)
+	if err != nil {
+		return err
+	}
+
+	if opts.Profile != "" {
+		fills = slices.DeleteFunc(fills, func(r fillRecord) bool { return r.Profile != opts.Profile })
+	}
+
+	out := outputOrStdout(opts.Out)
+	if len(fills) == 0 && len(windows) == 0 {
+		_, err := fmt.Fprintf(out, "no history in %s yet; run schedule --loop to collect it\n", cfg.StateDir)
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	writeFillTable(w, "CLASS", fills, func(r fillRecord) string { return r.Club + " / " + r.Title })
+	writeFillTable(w, "TRAINER", fills, func(r fillRecord) string { return r.Trainer })
+	writeFillTable(w, "CLUB", fills, func(r fillRecord) string { return r.Club })
+
+	type tally struct{ windows, booked, missed, skipped int }
+	tallies := make(map[string]*tally)
+	for _, record := range windows {
+		if opts.Profile != "" && record.Profile != opts.Profile {
+			continue
+		}
+		key := record.Profile + "\t" + record.Club + "\t" + record.Interest
+		t, ok := tallies[key]
+		if !ok {
+			t = &tally{}
+			tallies[key] = t
+		}
+		t.windows++
+		switch record.Outcome {
+		case windowBooked:
+			t.booked++
+		case windowMissed:
+			t.missed++
+		case windowSkipped:
+			t.skipped++
+		}
+	}
+
+	fmt.Fprintln(w, "PROFILE\tCLUB\tINTEREST\tWINDOWS\tBOOKED\tMISSED\tSKIPPED\tSUCCESS")
+	for _, key := range sortedKeys(tallies) {
+		t := tallies[key]
+		rate := "-"
+		if attempted := t.windows - t.skipped; attempted > 0 {
+			rate = fmt.Sprintf("%.0f%%", 100*float64(t.booked)/float64(attempted))
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%s\n", key, t.windows, t.booked, t.missed, t.skipped, rate)
+	}
+	return w.Flush()
+}
+
+// writeFillTable prints the median time-to-full of the fill samples grouped by key, followed by a blank line.
+func writeFillTable(w io.Writer, label string, fills []fillRecord, key func(fillRecord) string) {
+	type group struct {
+		samples int
+		filled  []time.Duration
+	}
+	groups := make(map[string]*group)
+	for _, record := range fills {
+		name := strings.TrimSpace(key(record))
+		if name == "" {
+			continue
+		}
+		g, ok := groups[name]
+		if !ok {
+			g = &group{}
+			groups[name] = g
+		}
+		g.samples++
+		if record.FilledAt != nil {
+			g.filled = append(g.filled, record.FilledAt.Sub(record.WindowOpen))
+		}
+	}
+	if len(groups) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "%s\tSAMPLES\tFILLED\tMEDIAN TIME TO FULL\n", label)
+	for _, name := range sortedKeys(groups) {
+		g := groups[name]
+		median := "-"
+		if len(g.filled) > 0 {
+			median = medianDuration(g.filled).Round(time.Second).String()
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", name, g.samples, len(g.filled), median)
+	}
+	fmt.Fprintln(w)
+}
+
+func medianDuration(values []time.Duration) time.Duration {
+	sorted := append([]time.Duration(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[mid]
+	}
+	return (sorted[mid-1] + sorted[mid]) / 2
+}
@@ -0,0 +1,103 @@
+package worldclass
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSampleFill(t *testing.T) {
+	start := time.Date(2026, 10, 19, 18, 0, 0, 0, time.UTC)
+	windowOpen := start.Add(-bookingLeadTime)
+	interest := ClassInterest{Title: "PILATES", DayEnglish: "Monday", Time: "18:00 - 19:00"}
+	bookable := Class{ClubID: "454", ClubName: "Park Lake", Day: "Luni 19.10", Time: "18:00 - 19:00", Title: "PILATES", ClassID: "1", Bookable: true}
+	full := bookable
+	full.Bookable = false
+	booked := bookable
+	booked.Bookable, booked.Booked = false, true
+
+	// Samples are taken at the open and then 1, 2 and 4 minutes apart.
+	filledAt := windowOpen.Add(7 * fillSampleInitial)
+	tests := []struct {
+		name      string
+		now       time.Time
+		schedules [][]Class
+		want      []fillRecord
+	}{
+		{
+			name:      "filled after the open",
+			now:       WakeTime(start),
+			schedules: [][]Class{{bookable}, {bookable}, {bookable}, {full}},
+			want:      []fillRecord{{Profile: "test", Club: "Park Lake", Title: "PILATES", ClassID: "1", Start: start, WindowOpen: windowOpen, FilledAt: &filledAt}},
+		},
+		{
+			name:      "booked by the account",
+			now:       WakeTime(start),
+			schedules: [][]Class{{bookable}, {booked}},
+			want:      []fillRecord{{Profile: "test", Club: "Park Lake", Title: "PILATES", ClassID: "1", Start: start, WindowOpen: windowOpen, Booked: true}},
+		},
+		{
+			name:      "window reached late",
+			now:       windowOpen.Add(10 * time.Minute),
+			schedules: [][]Class{{full}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			acct := newTestAccount(&fakeFetcher{schedules: tt.schedules}, &fakeBooker{})
+			acct.clock = &fakeClock{now: tt.now}
+			acct.store = newStateStore(t.TempDir())
+			handle := &scheduledInterest{Club: "Park Lake", Interest: interest, LastStart: start}
+
+			newScheduler(acct, time.UTC).sampleFill(context.Background(), handle, start)
+
+			got, err := readRecords[fillRecord](acct.store, fillsFile)
+			if err != nil {
+				t.Fatalf("read fills: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("recorded %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if !fillRecordsEqual(got[i], tt.want[i]) {
+					t.Errorf("record %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func fillRecordsEqual(a, b fillRecord) bool {
+	if (a.FilledAt == nil) != (b.FilledAt == nil) || (a.FilledAt != nil && !a.FilledAt.Equal(*b.FilledAt)) {
+		return false
+	}
+	a.FilledAt, b.FilledAt = nil, nil
+	return a.Start.Equal(b.Start) && a.WindowOpen.Equal(b.WindowOpen) &&
+		a.Profile == b.Profile && a.Club == b.Club && a.Title == b.Title && a.ClassID == b.ClassID && a.Booked == b.Booked
+}
+
+func TestRunStatsFiltersFillsByProfile(t *testing.T) {
+	dir := t.TempDir()
+	store := newStateStore(dir)
+	filledAt := time.Date(2026, 10, 18, 16, 5, 0, 0, time.UTC)
+	for _, record := range []fillRecord{
+		{Profile: "ana", Club: "Park Lake", Title: "PILATES", WindowOpen: filledAt.Add(-5 * time.Minute), FilledAt: &filledAt},
+		{Profile: "dan", Club: "Titan Park", Title: "BODYPUMP", WindowOpen: filledAt.Add(-5 * time.Minute), FilledAt: &filledAt},
+	} {
+		if err := store.append(fillsFile, record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	cfg := &Config{StateDir: dir, Profiles: []Profile{{Name: "ana"}, {Name: "dan"}}}
+	if err := RunStats(cfg, StatsOptions{Profile: "ana", Out: &out}); err != nil {
+		t.Fatalf("RunStats: %v", err)
+	}
+	if !strings.Contains(out.String(), "Park Lake / PILATES") || strings.Contains(out.String(), "BODYPUMP") {
+		t.Errorf("stats for ana:\n%s", out.String())
+	}
+}
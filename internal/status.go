@@ -0,0 +1,95 @@
+package worldclass
+
+import (
+	"sync"
+	"time"
+)
+
+// Loop phases reported by LoopStatus.
+const (
+	LoopPhaseStarting = "starting"
+	LoopPhaseIdle     = "idle"
+	LoopPhaseSleeping = "sleeping"
+	LoopPhaseBooking  = "booking"
+	LoopPhaseStopped  = "stopped"
+)
+
+// LoopStatus is a point-in-time view of one account's booking loop.
+type LoopStatus struct {
+	Profile string
+	Phase   string
+	// Club, Title, Start and Wake describe the occurrence the loop is waiting for or booking.
+	Club  string
+	Title string
+	Start time.Time
+	Wake  time.Time
+	// LastResult summarizes the most recent booking window, at LastResultAt.
+	LastResult   string
+	LastResultAt time.Time
+	LastError    string
+}
+
+// loopState records what a Scheduler is doing so it can be shown while the loop runs. A nil *loopState
+// ignores every update.
+type loopState struct {
+	mu     sync.Mutex
+	status LoopStatus
+}
+
+func newLoopState(profile string) *loopState {
+	return &loopState{status: LoopStatus{Profile: profile, Phase: LoopPhaseStarting}}
+}
+
+func (s *loopState) snapshot() LoopStatus {
+	if s == nil {
+		return LoopStatus{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+func (s *loopState) setPhase(phase string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.Phase = phase
+	if phase == LoopPhaseIdle {
+		s.status.Club, s.status.Title = "", ""
+		s.status.Start, s.status.Wake = time.Time{}, time.Time{}
+	}
+}
+
+func (s *loopState) setTarget(phase string, handle *scheduledInterest, start, wake time.Time) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.Phase = phase
+	s.status.Club = handle.Club
+	s.status.Title = interestLabel(handle.Interest)
+	s.status.Start = start
+	s.status.Wake = wake
+}
+
+func (s *loopState) setResult(result string, at time.Time) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.LastResult = result
+	s.status.LastResultAt = at
+}
+
+func (s *loopState) setError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.LastError = err.Error()
+}
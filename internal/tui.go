@@ -0,0 +1,493 @@
+package worldclass
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// TUIOptions controls the behavior of RunTUI.
+type TUIOptions struct {
+	Profile    string
+	ConfigPath string
+	// Logger receives diagnostics; it must not write to the terminal the UI draws on. Nil discards them.
+	Logger *slog.Logger
+}
+
+// Class states shown in the week grid.
+const (
+	classStateBookable = "bookable"
+	classStateBooked   = "booked"
+	classStateFull     = "full"
+	classStateClosed   = "closed"
+)
+
+var (
+	tuiTitleStyle    = lipgloss.NewStyle().Bold(true)
+	tuiTabStyle      = lipgloss.NewStyle().Padding(0, 1)
+	tuiActiveTab     = tuiTabStyle.Reverse(true)
+	tuiDayStyle      = lipgloss.NewStyle().Bold(true).Underline(true)
+	tuiSelectedStyle = lipgloss.NewStyle().Reverse(true)
+	tuiHelpStyle     = lipgloss.NewStyle().Faint(true)
+	tuiStateStyles   = map[string]lipgloss.Style{
+		classStateBookable: lipgloss.NewStyle().Foreground(lipgloss.Color("2")),
+		classStateBooked:   lipgloss.NewStyle().Foreground(lipgloss.Color("4")).Bold(true),
+		classStateFull:     lipgloss.NewStyle().Foreground(lipgloss.Color("1")),
+		classStateClosed:   lipgloss.NewStyle().Faint(true),
+	}
+)
+
+// gridWeekdays orders the grid columns Monday first, as the site does.
+var gridWeekdays = []time.Weekday{
+	time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday,
+}
+
+// RunTUI opens a terminal UI with a week grid per club for browsing, booking and cancelling classes and
+// toggling interests.
+func RunTUI(cfg *Config, opts TUIOptions) error {
+	profileIdx, profile, err := editableProfile(cfg, opts.Profile)
+	if err != nil {
+		return err
+	}
+
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return fmt.Errorf("load timezone %s: %w", cfg.Timezone, err)
+	}
+
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+	acct, err := newAccount(cfg, profile, nil, logger)
+	if err != nil {
+		return err
+	}
+
+	model := &tuiModel{
+		configPath: opts.ConfigPath,
+		profileIdx: profileIdx,
+		acct:       acct,
+		location:   location,
+		loading:    true,
+	}
+	_, err = tea.NewProgram(model, tea.WithAltScreen()).Run()
+	return err
+}
+
+type tuiModel struct {
+	configPath string
+	profileIdx int
+	acct       *account
+	location   *time.Location
+	session    BookingSession
+
+	classes []Class
+	clubIdx int
+	day     int
+	row     int
+
+	filter    string
+	filtering bool
+	loading   bool
+	status    string
+
+	width  int
+	height int
+}
+
+type tuiClassesMsg struct {
+	classes []Class
+	err     error
+}
+
+type tuiActionMsg struct {
+	status  string
+	err     error
+	session BookingSession
+	profile *Profile
+}
+
+func (m *tuiModel) Init() tea.Cmd {
+	return m.loadClasses()
+}
+
+func (m *tuiModel) loadClasses() tea.Cmd {
+	acct := m.acct
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		classes, err := acct.fetcher.FetchClasses(ctx, acct.profile.Credentials, acct.displayClubs())
+		return tuiClassesMsg{classes: classes, err: err}
+	}
+}
+
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+
+	case tuiClassesMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.status = "fetch failed: " + msg.err.Error()
+			return m, nil
+		}
+		m.classes = msg.classes
+		m.clampSelection()
+		return m, nil
+
+	case tuiActionMsg:
+		if msg.err != nil {
+			m.session = nil
+			m.status = msg.err.Error()
+			return m, nil
+		}
+		if msg.session != nil {
+			m.session = msg.session
+		}
+		m.status = msg.status
+		if msg.profile != nil {
+			m.acct.profile = *msg.profile
+			return m, nil
+		}
+		m.loading = true
+		return m, m.loadClasses()
+
+	case tea.KeyMsg:
+		if m.filtering {
+			return m.updateFilter(msg)
+		}
+		return m.updateGrid(msg)
+	}
+	return m, nil
+}
+
+func (m *tuiModel) updateFilter(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.filtering = false
+	case tea.KeyEsc:
+		m.filtering = false
+		m.filter = ""
+	case tea.KeyBackspace:
+		if runes := []rune(m.filter); len(runes) > 0 {
+			m.filter = string(runes[:len(runes)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		m.filter += string(msg.Runes)
+	}
+	m.clampSelection()
+	return m, nil
+}
+
+func (m *tuiModel) updateGrid(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return m, tea.Quit
+	case "left", "h":
+		m.day = (m.day + len(gridWeekdays) - 1) % len(gridWeekdays)
+	case "right", "l":
+		m.day = (m.day + 1) % len(gridWeekdays)
+	case "up", "k":
+		if m.row > 0 {
+			m.row--
+		}
+	case "down", "j":
+		m.row++
+	case "tab":
+		if clubs := m.acct.profile.Clubs; len(clubs) > 0 {
+			m.clubIdx = (m.clubIdx + 1) % len(clubs)
+		}
+	case "shift+tab":
+		if clubs := m.acct.profile.Clubs; len(clubs) > 0 {
+			m.clubIdx = (m.clubIdx + len(clubs) - 1) % len(clubs)
+		}
+	case "/":
+		m.filtering = true
+	case "esc":
+		m.filter = ""
+	case "r":
+		m.loading = true
+		m.status = ""
+		return m, m.loadClasses()
+	case "b":
+		if classInfo, ok := m.selected(); ok {
+			return m, m.book(classInfo)
+		}
+	case "c":
+		if classInfo, ok := m.selected(); ok {
+			return m, m.cancel(classInfo)
+		}
+	case "i":
+		if classInfo, ok := m.selected(); ok {
+			return m, m.toggleInterest(classInfo)
+		}
+	}
+	m.clampSelection()
+	return m, nil
+}
+
+func (m *tuiModel) book(classInfo Class) tea.Cmd {
+	if classInfo.Booked {
+		m.status = classInfo.Title + " is already booked"
+		return nil
+	}
+	m.status = "booking " + classInfo.Title + "..."
+	return m.sessionAction(classInfo, historyBooked, func(ctx context.Context, session BookingSession) error {
+		return session.Book(ctx, classInfo.ClubID, classInfo.ClassID)
+	})
+}
+
+func (m *tuiModel) cancel(classInfo Class) tea.Cmd {
+	if !classInfo.Booked {
+		m.status = classInfo.Title + " is not booked"
+		return nil
+	}
+	m.status = "cancelling " + classInfo.Title + "..."
+	return m.sessionAction(classInfo, historyCancelled, func(ctx context.Context, session BookingSession) error {
+		return session.Cancel(ctx, classInfo.CancelURL)
+	})
+}
+
+// sessionAction runs action with the cached booking session, logging in first when there is none yet. A failed
+// action drops the session, since an expired one would fail every later action too.
+func (m *tuiModel) sessionAction(classInfo Class, verb string, action func(context.Context, BookingSession) error) tea.Cmd {
+	acct, session := m.acct, m.session
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if session == nil {
+			var err error
+			session, err = acct.booker.Login(ctx, acct.profile.Credentials)
+			if err != nil {
+				return tuiActionMsg{err: fmt.Errorf("login failed: %w", err)}
+			}
+		}
+		if err := action(ctx, session); err != nil {
+			return tuiActionMsg{err: fmt.Errorf("%s: %w", classInfo.Title, err)}
+		}
+		acct.recordBooking(acct.profile.Name, verb, classInfo, sourceTUI)
+		return tuiActionMsg{status: fmt.Sprintf("%s %s (%s %s)", verb, classInfo.Title, classInfo.Day, classInfo.Time), session: session}
+	}
+}
+
+// toggleInterest removes the interest created from classInfo if there is one, or adds it otherwise.
+func (m *tuiModel) toggleInterest(classInfo Class) tea.Cmd {
+	if m.configPath == "" {
+		m.status = "no config file to edit"
+		return nil
+	}
+	configPath, profileIdx, profile := m.configPath, m.profileIdx, m.acct.profile
+	return func() tea.Msg {
+		interest, err := interestFromClass(classInfo)
+		if err != nil {
+			return tuiActionMsg{err: err}
+		}
+
+		status := "added interest " + classInfo.Title
+		existing := -1
+		for idx, candidate := range profile.Interests[classInfo.ClubName] {
+			if interestsEqual(candidate, interest) {
+				existing = idx
+				break
+			}
+		}
+		if existing >= 0 {
+			err = removeInterestFromConfig(configPath, profileIdx, classInfo.ClubName, existing)
+			status = "removed interest " + classInfo.Title
+		} else {
+			err = addInterestToConfig(configPath, profileIdx, classInfo.ClubName, interest)
+		}
+		if err != nil {
+			return tuiActionMsg{err: err}
+		}
+
+		cfg, err := LoadConfig(configPath)
+		if err != nil {
+			return tuiActionMsg{err: err}
+		}
+		updated := cfg.Profiles[profileIdx]
+		return tuiActionMsg{status: status, profile: &updated}
+	}
+}
+
+// column returns the classes of the current club on the grid's weekday at idx that pass the filter,
+// ordered by start time.
+func (m *tuiModel) column(idx int) []Class {
+	clubs := m.acct.profile.Clubs
+	if len(clubs) == 0 {
+		return nil
+	}
+	club := clubs[m.clubIdx]
+	needle := strings.ToLower(strings.TrimSpace(m.filter))
+
+	var column []Class
+	for _, classInfo := range m.classes {
+		if classInfo.ClubID != club.ID {
+			continue
+		}
+		if weekday, ok := dayLabelWeekday(classInfo.Day); !ok || weekday != gridWeekdays[idx] {
+			continue
+		}
+		if needle != "" && !strings.Contains(strings.ToLower(classInfo.Title), needle) &&
+			!strings.Contains(strings.ToLower(classInfo.Trainer), needle) {
+			continue
+		}
+		column = append(column, classInfo)
+	}
+	sort.SliceStable(column, func(i, j int) bool {
+		return column[i].Time < column[j].Time
+	})
+	return column
+}
+
+func (m *tuiModel) selected() (Class, bool) {
+	column := m.column(m.day)
+	if m.row < 0 || m.row >= len(column) {
+		return Class{}, false
+	}
+	return column[m.row], true
+}
+
+func (m *tuiModel) clampSelection() {
+	if n := len(m.column(m.day)); m.row >= n {
+		m.row = max(n-1, 0)
+	}
+}
+
+func (m *tuiModel) isInterest(classInfo Class) bool {
+	for _, interest := range m.acct.profile.Interests[classInfo.ClubName] {
+		if interestMatches(classInfo, interest, m.acct.clock.Now(), nil) {
+			return true
+		}
+	}
+	return false
+}
+
+// classState tells bookable, booked, full (window open but no booking button) and closed classes apart.
+func classState(classInfo Class, location *time.Location, now time.Time) string {
+	switch {
+	case classInfo.Booked:
+		return classStateBooked
+	case classInfo.Bookable:
+		return classStateBookable
+	}
+	start, ok := classDate(classInfo, location, now)
+	if ok && now.After(start.Add(-bookingLeadTime)) && now.Before(start) {
+		return classStateFull
+	}
+	return classStateClosed
+}
+
+func (m *tuiModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(tuiTitleStyle.Render("World Class — " + m.acct.profile.Name))
+	b.WriteString("\n")
+
+	var tabs []string
+	for idx, club := range m.acct.profile.Clubs {
+		style := tuiTabStyle
+		if idx == m.clubIdx {
+			style = tuiActiveTab
+		}
+		tabs = append(tabs, style.Render(club.Name))
+	}
+	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, tabs...))
+	b.WriteString("\n\n")
+
+	if m.loading {
+		b.WriteString("loading schedule...\n")
+	}
+
+	width := m.width
+	if width <= 0 {
+		width = 120
+	}
+	colWidth := max(width/len(gridWeekdays)-1, 12)
+	// Leave room for the title, tabs, day headers, detail pane and help lines.
+	visibleRows := max(m.height-14, 3)
+
+	now := m.acct.clock.Now().In(m.location)
+	columns := make([]string, 0, len(gridWeekdays))
+	for idx, weekday := range gridWeekdays {
+		classes := m.column(idx)
+		header := weekday.String()
+		if len(classes) > 0 {
+			header = classes[0].Day
+		}
+		lines := []string{tuiDayStyle.Render(truncate(header, colWidth))}
+
+		offset := 0
+		if idx == m.day && m.row >= visibleRows {
+			offset = m.row - visibleRows + 1
+		}
+		for row := offset; row < len(classes) && row < offset+visibleRows; row++ {
+			classInfo := classes[row]
+			marker := " "
+			if m.isInterest(classInfo) {
+				marker = "*"
+			}
+			start, _, _ := strings.Cut(classInfo.Time, " ")
+			cell := truncate(marker+start+" "+classInfo.Title, colWidth)
+			style := tuiStateStyles[classState(classInfo, m.location, now)]
+			if idx == m.day && row == m.row {
+				style = tuiSelectedStyle
+			}
+			lines = append(lines, style.Width(colWidth).Render(cell))
+		}
+		columns = append(columns, lipgloss.NewStyle().Width(colWidth).MarginRight(1).Render(strings.Join(lines, "\n")))
+	}
+	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, columns...))
+	b.WriteString("\n\n")
+
+	if classInfo, ok := m.selected(); ok {
+		state := classState(classInfo, m.location, now)
+		b.WriteString(tuiTitleStyle.Render(classInfo.Title))
+		fmt.Fprintf(&b, "  %s %s  %s\n", classInfo.Day, classInfo.Time, tuiStateStyles[state].Render(state))
+		fmt.Fprintf(&b, "trainer: %s  room: %s  id: %s", classInfo.Trainer, classInfo.Room, classInfo.ClassID)
+		if classInfo.Category != "" {
+			fmt.Fprintf(&b, "  category: %s", classInfo.Category)
+		}
+		if m.isInterest(classInfo) {
+			b.WriteString("  (interest)")
+		}
+		b.WriteString("\n")
+	} else {
+		b.WriteString("no class selected\n\n")
+	}
+
+	switch {
+	case m.filtering:
+		fmt.Fprintf(&b, "filter: %s_\n", m.filter)
+	case m.filter != "":
+		fmt.Fprintf(&b, "filter: %s (esc clears)\n", m.filter)
+	default:
+		b.WriteString("\n")
+	}
+	b.WriteString(m.status)
+	b.WriteString("\n")
+	b.WriteString(tuiHelpStyle.Render("←/→ day  ↑/↓ class  tab club  / filter  b book  c cancel  i interest  r refresh  q quit"))
+	return b.String()
+}
+
+// truncate shortens s to width runes, marking the cut with an ellipsis.
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	if width <= 1 {
+		return string(runes[:width])
+	}
+	return string(runes[:width-1]) + "…"
+}
@@ -0,0 +1,173 @@
+package worldclass
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestClassState(t *testing.T) {
+	class := Class{Day: "Luni 19.10", Time: "18:00 - 19:00", Title: "PILATES"}
+	start := time.Date(2026, 10, 19, 18, 0, 0, 0, time.UTC)
+	opens := start.Add(-bookingLeadTime)
+	booked, bookable := class, class
+	booked.Booked = true
+	bookable.Bookable = true
+
+	tests := []struct {
+		name  string
+		class Class
+		now   time.Time
+		want  string
+	}{
+		{name: "booked", class: booked, now: start.Add(-time.Hour), want: classStateBooked},
+		{name: "bookable", class: bookable, now: start.Add(-time.Hour), want: classStateBookable},
+		{name: "before the window", class: class, now: opens.Add(-time.Minute), want: classStateClosed},
+		{name: "as the window opens", class: class, now: opens, want: classStateClosed},
+		{name: "inside the window", class: class, now: opens.Add(time.Minute), want: classStateFull},
+		{name: "just before the start", class: class, now: start.Add(-time.Minute), want: classStateFull},
+		{name: "after the start", class: class, now: start, want: classStateClosed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classState(tt.class, time.UTC, tt.now); got != tt.want {
+				t.Errorf("classState() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func newTestTUIModel(classes []Class) *tuiModel {
+	acct := newTestAccount(&fakeFetcher{}, &fakeBooker{})
+	acct.profile.Clubs = []Club{{ID: "454", Name: "Park Lake"}, {ID: "458", Name: "Titan Park"}}
+	return &tuiModel{acct: acct, location: time.UTC, classes: classes}
+}
+
+func TestTUIColumn(t *testing.T) {
+	classes := []Class{
+		{ClubID: "454", Day: "Luni 19.10", Time: "19:00 - 20:00", Title: "SPINNING", Trainer: "Ioana", ClassID: "1"},
+		{ClubID: "454", Day: "Luni 19.10", Time: "07:30 - 08:30", Title: "PILATES", Trainer: "Dan", ClassID: "2"},
+		{ClubID: "454", Day: "Marti 20.10", Time: "18:00 - 19:00", Title: "YOGA", ClassID: "3"},
+		{ClubID: "458", Day: "Luni 19.10", Time: "18:00 - 19:00", Title: "PILATES", ClassID: "4"},
+	}
+
+	tests := []struct {
+		name    string
+		clubIdx int
+		day     int
+		filter  string
+		want    []string
+	}{
+		{name: "monday ordered by start", want: []string{"2", "1"}},
+		{name: "tuesday", day: 1, want: []string{"3"}},
+		{name: "other club", clubIdx: 1, want: []string{"4"}},
+		{name: "filter by title", filter: "spin", want: []string{"1"}},
+		{name: "filter by trainer", filter: " dan ", want: []string{"2"}},
+		{name: "empty day", day: 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestTUIModel(classes)
+			m.clubIdx, m.filter = tt.clubIdx, tt.filter
+
+			var got []string
+			for _, classInfo := range m.column(tt.day) {
+				got = append(got, classInfo.ClassID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("column(%d) = %v, want %v", tt.day, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTUIClampSelection(t *testing.T) {
+	monday := []Class{
+		{ClubID: "454", Day: "Luni 19.10", Time: "18:00 - 19:00", Title: "PILATES"},
+		{ClubID: "454", Day: "Luni 19.10", Time: "19:00 - 20:00", Title: "SPINNING"},
+	}
+
+	tests := []struct {
+		name    string
+		classes []Class
+		row     int
+		want    int
+	}{
+		{name: "inside the column", classes: monday, row: 1, want: 1},
+		{name: "past the end", classes: monday, row: 5, want: 1},
+		{name: "empty column", row: 3, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestTUIModel(tt.classes)
+			m.row = tt.row
+			m.clampSelection()
+			if m.row != tt.want {
+				t.Errorf("row = %d, want %d", m.row, tt.want)
+			}
+		})
+	}
+}
+
+func TestTUIToggleInterest(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	config := `profiles:
+  - name: test
+    credentials:
+      email: test@example.com
+      password: secret
+    clubs:
+      - id: "454"
+        name: "Park Lake"
+`
+	if err := os.WriteFile(configPath, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	pilates := Class{ClubID: "454", ClubName: "Park Lake", Day: "Luni 19.10", Time: "18:00 - 19:00", Title: "PILATES"}
+	m := newTestTUIModel([]Class{pilates})
+	m.configPath = configPath
+
+	for _, want := range []int{1, 0} {
+		msg := m.toggleInterest(pilates)()
+		if action := msg.(tuiActionMsg); action.err != nil {
+			t.Fatalf("toggleInterest: %v", action.err)
+		}
+		m.Update(msg)
+
+		if got := len(m.acct.profile.Interests["Park Lake"]); got != want {
+			t.Fatalf("%d interests after toggling, want %d", got, want)
+		}
+		cfg, err := LoadConfig(configPath)
+		if err != nil {
+			t.Fatalf("LoadConfig: %v", err)
+		}
+		if got := len(cfg.Profiles[0].Interests["Park Lake"]); got != want {
+			t.Fatalf("config file has %d interests, want %d", got, want)
+		}
+	}
+}
+
+func TestTUIActionErrorDropsSession(t *testing.T) {
+	booker := &fakeBooker{bookErr: map[string]error{"1": errors.New("session expired")}}
+	m := newTestTUIModel(nil)
+	m.acct.booker = booker
+	m.session = fakeSession{booker: booker}
+
+	msg := m.sessionAction(Class{ClassID: "1", Title: "PILATES"}, historyBooked, func(ctx context.Context, session BookingSession) error {
+		return session.Book(ctx, "454", "1")
+	})()
+	if action := msg.(tuiActionMsg); action.err == nil || action.session != nil {
+		t.Fatalf("sessionAction() = %+v, want an error without a session", action)
+	}
+	m.Update(msg)
+	if m.session != nil {
+		t.Error("the failed session is still cached")
+	}
+}
@@ -0,0 +1,594 @@
+package worldclass
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocolly/colly"
+)
+
+const defaultUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10.15; rv:144.0) Gecko/20100101 Firefox/144.0"
+
+// maxActionBody bounds how much of a booking or cancellation response is read to recognise the login page.
+const maxActionBody = 1 << 20
+
+// Credentials are the member account email and password.
+type Credentials struct {
+	Email    string `yaml:"email"`
+	Password string `yaml:"password"`
+}
+
+// Club identifies a club by the ID used in the member-schedule form and a display name.
+type Club struct {
+	ID   string `yaml:"id"`
+	Name string `yaml:"name"`
+	// Groups restricts the schedule to these class categories; empty fetches every category.
+	Groups []Group `yaml:"groups,omitempty"`
+}
+
+// Group is a class category offered by the schedule's group selector (e.g. Cardio, Aqua).
+// A Group with only a Name is resolved to its ID from the selector when fetching.
+type Group struct {
+	ID   string `yaml:"id,omitempty"`
+	Name string `yaml:"name"`
+}
+
+// AllGroupsID is the group selector value that lists every category.
+const AllGroupsID = "-1"
+
+// Class is one scheduled class scraped from a club's schedule page.
+type Class struct {
+	ClubID   string
+	ClubName string
+	Day      string
+	Time     string
+	Title    string
+	Trainer  string
+	Room     string
+	// Category is the group the class was fetched under; it is empty when the club was fetched without groups.
+	Category string
+	ClassID  string
+	Bookable bool
+	Booked   bool
+	// CancelURL is the absolute href of the class's cancel link; it is only set for booked classes.
+	CancelURL string
+}
+
+// Client wraps the scraping and booking interactions with the World Class member site.
+type Client struct {
+	baseURL *url.URL
+	logger  *slog.Logger
+}
+
+// Session is an authenticated connection that can book and cancel classes.
+type Session struct {
+	client  *http.Client
+	baseURL *url.URL
+}
+
+// NewClient creates a configured client that targets the provided base URL. A nil logger uses slog.Default.
+func NewClient(rawBaseURL string, logger *slog.Logger) (*Client, error) {
+	if rawBaseURL == "" {
+		return nil, errors.New("base URL is required")
+	}
+
+	parsedURL, err := url.Parse(rawBaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse base URL: %w", err)
+	}
+
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &Client{
+		baseURL: parsedURL,
+		logger:  logger,
+	}, nil
+}
+
+// FetchClasses scrapes the club schedule pages and returns every class that matches the provided clubs.
+func (c *Client) FetchClasses(ctx context.Context, creds Credentials, clubs []Club) ([]Class, error) {
+	if c == nil || c.baseURL == nil {
+		return nil, ErrNotInitialised
+	}
+
+	if creds.Email == "" || creds.Password == "" {
+		return nil, ErrMissingCredentials
+	}
+
+	if len(clubs) == 0 {
+		return nil, ErrNoClubs
+	}
+
+	collector := c.newCollector(ctx)
+
+	var (
+		classes   []Class
+		classesMu sync.Mutex
+	)
+
+	collector.OnHTML(".daily-schedule", func(e *colly.HTMLElement) {
+		clubName := e.Request.Ctx.Get("clubName")
+		if clubName == "" {
+			clubName = "Unknown club"
+		}
+		clubID := e.Request.Ctx.Get("clubID")
+		category := e.Request.Ctx.Get("category")
+
+		day := e.ChildText("div.schedule-day>strong")
+		e.ForEach(".schedule-class", func(_ int, el *colly.HTMLElement) {
+			classButton := el.DOM.Find(".btn-book-class")
+			hasBookButton := len(classButton.Nodes) > 0
+			alreadyBooked := false
+			cancelURL := ""
+			if hasBookButton {
+				alreadyBooked = classButton.HasClass("cancel-link")
+			}
+			if alreadyBooked {
+				cancelURL = cancelHref(e.Request, classButton.AttrOr("href", ""))
+			}
+
+			classID := el.ChildAttr("div.col-xs-5.col-sm-12.text-right>a", "data-target")
+			classID = strings.TrimPrefix(classID, "#")
+			classID = strings.TrimPrefix(classID, "class-")
+
+			classInfo := Class{
+				ClubID:    clubID,
+				ClubName:  clubName,
+				Day:       day,
+				Time:      el.ChildText("div.col-xs-7.col-sm-12>span.class-hours"),
+				Room:      el.ChildText("div.col-xs-7.col-sm-12>span.room"),
+				Title:     el.ChildText("div.col-xs-7.col-sm-12>strong.class-title"),
+				Trainer:   el.ChildText("div.col-xs-7.col-sm-12>span.trainers"),
+				Category:  category,
+				ClassID:   classID,
+				Bookable:  hasBookButton && !alreadyBooked,
+				Booked:    alreadyBooked,
+				CancelURL: cancelURL,
+			}
+
+			classesMu.Lock()
+			classes = append(classes, classInfo)
+			classesMu.Unlock()
+		})
+	})
+
+	if err := c.collectorLogin(collector, creds); err != nil {
+		return nil, err
+	}
+
+	// Groups given only by name need the selector's IDs; the clone shares the logged-in cookie jar.
+	var available []Group
+	if needsGroupLookup(clubs) {
+		scraped, err := c.scrapeGroups(collector.Clone())
+		if err != nil {
+			return nil, err
+		}
+		available = scraped
+	}
+
+	scheduleURL := c.baseURL.JoinPath("member-schedule.php")
+	for _, club := range clubs {
+		groups := club.Groups
+		if len(groups) == 0 {
+			groups = []Group{{ID: AllGroupsID}}
+		}
+
+		for _, group := range groups {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			default:
+			}
+
+			group, err := resolveGroup(group, available)
+			if err != nil {
+				return nil, fmt.Errorf("club %s (%s): %w", club.Name, club.ID, err)
+			}
+
+			form := url.Values{}
+			form.Set("clubid", club.ID)
+			form.Set("group", group.ID)
+
+			reqCtx := colly.NewContext()
+			reqCtx.Put("clubName", club.Name)
+			reqCtx.Put("clubID", club.ID)
+			if group.ID != AllGroupsID {
+				reqCtx.Put("category", group.Name)
+			}
+			if err := collector.Request(
+				http.MethodPost,
+				scheduleURL.String(),
+				strings.NewReader(form.Encode()),
+				reqCtx,
+				http.Header{
+					"Content-Type": []string{"application/x-www-form-urlencoded"},
+				},
+			); err != nil {
+				return nil, fmt.Errorf("request schedule for club %s (%s): %w", club.Name, club.ID, err)
+			}
+		}
+	}
+
+	collector.Wait()
+
+	return mergeClasses(classes), nil
+}
+
+// ListGroups logs in and returns the class categories offered by the schedule's group selector.
+func (c *Client) ListGroups(ctx context.Context, creds Credentials) ([]Group, error) {
+	if c == nil || c.baseURL == nil {
+		return nil, ErrNotInitialised
+	}
+
+	if creds.Email == "" || creds.Password == "" {
+		return nil, ErrMissingCredentials
+	}
+
+	collector := c.newCollector(ctx)
+	if err := c.collectorLogin(collector, creds); err != nil {
+		return nil, err
+	}
+
+	groups, err := c.scrapeGroups(collector)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// scrapeGroups loads the schedule page with a logged-in collector and reads the group selector.
+func (c *Client) scrapeGroups(collector *colly.Collector) ([]Group, error) {
+	var groups []Group
+	collector.OnHTML("select[name=group] option", func(e *colly.HTMLElement) {
+		id := strings.TrimSpace(e.Attr("value"))
+		if id == "" || id == AllGroupsID {
+			return
+		}
+		groups = append(groups, Group{ID: id, Name: strings.TrimSpace(e.Text)})
+	})
+
+	scheduleURL := c.baseURL.JoinPath("member-schedule.php")
+	if err := collector.Visit(scheduleURL.String()); err != nil {
+		return nil, fmt.Errorf("request schedule page: %w", err)
+	}
+	collector.Wait()
+
+	if len(groups) == 0 {
+		return nil, ErrNoGroupSelector
+	}
+	return groups, nil
+}
+
+func needsGroupLookup(clubs []Club) bool {
+	for _, club := range clubs {
+		for _, group := range club.Groups {
+			if group.ID == "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resolveGroup fills in the ID of a group given only by name, matching names case-insensitively.
+func resolveGroup(group Group, available []Group) (Group, error) {
+	if group.ID != "" {
+		return group, nil
+	}
+	for _, candidate := range available {
+		if strings.EqualFold(candidate.Name, strings.TrimSpace(group.Name)) {
+			return candidate, nil
+		}
+	}
+	return Group{}, fmt.Errorf("%w: %q", ErrUnknownGroup, group.Name)
+}
+
+// mergeClasses drops classes seen more than once for the same club, as happens when a club is
+// fetched both per category and across all categories, keeping the first non-empty category.
+func mergeClasses(classes []Class) []Class {
+	type key struct{ clubID, classID string }
+
+	merged := make([]Class, 0, len(classes))
+	index := make(map[key]int, len(classes))
+	for _, classInfo := range classes {
+		if classInfo.ClassID == "" {
+			merged = append(merged, classInfo)
+			continue
+		}
+		k := key{classInfo.ClubID, classInfo.ClassID}
+		if i, ok := index[k]; ok {
+			if merged[i].Category == "" {
+				merged[i].Category = classInfo.Category
+			}
+			continue
+		}
+		index[k] = len(merged)
+		merged = append(merged, classInfo)
+	}
+	return merged
+}
+
+// ListClubs logs in and returns every club offered by the club selector on the schedule page.
+func (c *Client) ListClubs(ctx context.Context, creds Credentials) ([]Club, error) {
+	if c == nil || c.baseURL == nil {
+		return nil, ErrNotInitialised
+	}
+
+	if creds.Email == "" || creds.Password == "" {
+		return nil, ErrMissingCredentials
+	}
+
+	collector := c.newCollector(ctx)
+
+	var clubs []Club
+	seen := make(map[string]bool)
+	collector.OnHTML("select[name=clubid] option", func(e *colly.HTMLElement) {
+		id := strings.TrimSpace(e.Attr("value"))
+		if id == "" || id == "-1" || seen[id] {
+			return
+		}
+		seen[id] = true
+		clubs = append(clubs, Club{ID: id, Name: strings.TrimSpace(e.Text)})
+	})
+
+	if err := c.collectorLogin(collector, creds); err != nil {
+		return nil, err
+	}
+
+	scheduleURL := c.baseURL.JoinPath("member-schedule.php")
+	if err := collector.Visit(scheduleURL.String()); err != nil {
+		return nil, fmt.Errorf("request schedule page: %w", err)
+	}
+	collector.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(clubs) == 0 {
+		return nil, ErrNoClubSelector
+	}
+	return clubs, nil
+}
+
+// newCollector returns a scraper bound to the site's host that aborts requests once ctx is done.
+func (c *Client) newCollector(ctx context.Context) *colly.Collector {
+	collector := colly.NewCollector(
+		colly.AllowedDomains(c.baseURL.Host),
+		colly.UserAgent(defaultUserAgent),
+	)
+	collector.SetRequestTimeout(15 * time.Second)
+	collector.WithTransport(&http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+	})
+
+	collector.OnRequest(func(r *colly.Request) {
+		select {
+		case <-ctx.Done():
+			r.Abort()
+			return
+		default:
+		}
+	})
+
+	collector.OnError(func(r *colly.Response, err error) {
+		c.logger.Warn("request failed", "url", r.Request.URL.String(), "error", err)
+	})
+
+	return collector
+}
+
+// collectorLogin signs the collector in so its cookie jar carries the member session.
+func (c *Client) collectorLogin(collector *colly.Collector, creds Credentials) error {
+	loginURL := c.baseURL.JoinPath("_process_login.php")
+	if err := collector.Post(loginURL.String(), map[string]string{
+		"email":           creds.Email,
+		"member_password": creds.Password,
+		"remember_me":     "false",
+	}); err != nil {
+		return fmt.Errorf("login: %w", err)
+	}
+	return nil
+}
+
+// ListBookings returns the classes in the given clubs' schedules that are booked for the account.
+func (c *Client) ListBookings(ctx context.Context, creds Credentials, clubs []Club) ([]Class, error) {
+	classes, err := c.FetchClasses(ctx, creds, clubs)
+	if err != nil {
+		return nil, err
+	}
+
+	booked := make([]Class, 0, len(classes))
+	for _, classInfo := range classes {
+		if classInfo.Booked {
+			booked = append(booked, classInfo)
+		}
+	}
+	return booked, nil
+}
+
+// Login authenticates with the member site and returns a Session that can submit booking requests.
+func (c *Client) Login(ctx context.Context, creds Credentials) (*Session, error) {
+	if c == nil || c.baseURL == nil {
+		return nil, ErrNotInitialised
+	}
+
+	if creds.Email == "" || creds.Password == "" {
+		return nil, ErrMissingCredentials
+	}
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, fmt.Errorf("create cookie jar: %w", err)
+	}
+
+	client := &http.Client{
+		Jar: jar,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	loginURL := c.baseURL.JoinPath("_process_login.php")
+	form := url.Values{
+		"email":           {creds.Email},
+		"member_password": {creds.Password},
+		"remember_me":     {"false"},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, loginURL.String(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("build login request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	setDefaultUserAgent(req)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("login request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound {
+		return nil, &LoginError{StatusCode: resp.StatusCode}
+	}
+
+	expected := c.baseURL.JoinPath("dashboard.php").String()
+	if loc := normalizeLocation(c.baseURL, resp.Header.Get("Location")); loc != expected {
+		return nil, &LoginError{StatusCode: resp.StatusCode, Location: loc}
+	}
+
+	return &Session{
+		client:  client,
+		baseURL: c.baseURL,
+	}, nil
+}
+
+// Book reserves a class via the booking endpoint. A refusal from the site is reported as an *ActionError.
+func (s *Session) Book(ctx context.Context, clubID, classID string) error {
+	if s == nil || s.client == nil || s.baseURL == nil {
+		return ErrNotInitialised
+	}
+
+	if clubID == "" || classID == "" {
+		return ErrMissingClass
+	}
+
+	actionURL := s.baseURL.JoinPath("_book_class.php")
+	query := url.Values{}
+	query.Set("id", classID)
+	query.Set("clubid", clubID)
+	actionURL.RawQuery = query.Encode()
+
+	return s.submitClassAction(ctx, "booking", actionURL)
+}
+
+// Cancel releases a booked class by following the cancel link scraped with it (Class.CancelURL). A refusal
+// from the site is reported as an *ActionError.
+func (s *Session) Cancel(ctx context.Context, cancelURL string) error {
+	if s == nil || s.client == nil || s.baseURL == nil {
+		return ErrNotInitialised
+	}
+
+	if cancelURL == "" {
+		return ErrNoCancelLink
+	}
+
+	ref, err := url.Parse(cancelURL)
+	if err != nil {
+		return fmt.Errorf("parse cancel link: %w", err)
+	}
+	actionURL := s.baseURL.ResolveReference(ref)
+	if actionURL.Host != s.baseURL.Host {
+		return fmt.Errorf("cancel link %s is not on %s", actionURL, s.baseURL.Host)
+	}
+
+	return s.submitClassAction(ctx, "cancel", actionURL)
+}
+
+// submitClassAction issues a GET against actionURL; the site answers both booking and cancellation requests
+// with a redirect back to the member schedule on success.
+func (s *Session) submitClassAction(ctx context.Context, action string, actionURL *url.URL) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, actionURL.String(), nil)
+	if err != nil {
+		return fmt.Errorf("build %s request: %w", action, err)
+	}
+	setDefaultUserAgent(req)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s request: %w", action, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusFound {
+		loc := normalizeLocation(s.baseURL, resp.Header.Get("Location"))
+		if loc == s.baseURL.JoinPath("member-schedule.php").String() {
+			return nil
+		}
+
+		return &ActionError{Action: action, StatusCode: resp.StatusCode, Location: loc}
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		// Some responses might not redirect but still indicate success. An expired session also answers with
+		// 200, serving the login form in place of the action.
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxActionBody))
+		if err != nil {
+			return fmt.Errorf("read %s response: %w", action, err)
+		}
+		if bytes.Contains(body, []byte(`name="member_password"`)) {
+			return &ActionError{Action: action, StatusCode: resp.StatusCode, Reason: "session expired, the site served its login page"}
+		}
+		return nil
+	}
+
+	return &ActionError{Action: action, StatusCode: resp.StatusCode}
+}
+
+// cancelHref resolves the href of a cancel link against the schedule page, ignoring placeholders such as "#"
+// and javascript: links that a script handles.
+func cancelHref(req *colly.Request, href string) string {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+		return ""
+	}
+	return req.AbsoluteURL(href)
+}
+
+// normalizeLocation resolves redirect locations against the base URL, producing absolute URLs for logging and comparisons.
+func normalizeLocation(base *url.URL, loc string) string {
+	if base == nil || loc == "" {
+		return loc
+	}
+
+	ref, err := url.Parse(loc)
+	if err != nil {
+		return loc
+	}
+
+	return base.ResolveReference(ref).String()
+}
+
+func setDefaultUserAgent(req *http.Request) {
+	if req == nil {
+		return
+	}
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", defaultUserAgent)
+	}
+}
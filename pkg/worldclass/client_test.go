@@ -0,0 +1,297 @@
+package worldclass
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/gocolly/colly"
+)
+
+var testCreds = Credentials{Email: "ana@example.com", Password: "secret"}
+
+// newTestSite serves the login and class action endpoints of the member site. Logins with testCreds
+// redirect to the dashboard; the class ID of a booking picks the response.
+func newTestSite(t *testing.T) (*httptest.Server, func() []string) {
+	t.Helper()
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/_process_login.php", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.FormValue("email") == "down@example.com":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case r.FormValue("email") == testCreds.Email && r.FormValue("member_password") == testCreds.Password:
+			http.Redirect(w, r, "/dashboard.php", http.StatusFound)
+		default:
+			http.Redirect(w, r, "/login.php?error=1", http.StatusFound)
+		}
+	})
+	mux.HandleFunc("/_book_class.php", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.URL.RequestURI())
+		mu.Unlock()
+		switch r.FormValue("id") {
+		case "booked":
+			http.Redirect(w, r, "/member-schedule.php", http.StatusFound)
+		case "full":
+			http.Redirect(w, r, "/member-schedule.php?error=full", http.StatusFound)
+		case "expired":
+			w.Write([]byte(`<form action="_process_login.php"><input name="email"><input type="password" name="member_password"></form>`))
+		case "plain":
+			w.Write([]byte(`<p>ok</p>`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+	mux.HandleFunc("/_cancel_class.php", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.URL.RequestURI())
+		mu.Unlock()
+		http.Redirect(w, r, "/member-schedule.php", http.StatusFound)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(requests)
+	}
+}
+
+func newTestClient(t *testing.T, baseURL string) *Client {
+	t.Helper()
+	client, err := NewClient(baseURL, nil)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return client
+}
+
+func TestLogin(t *testing.T) {
+	server, _ := newTestSite(t)
+	client := newTestClient(t, server.URL)
+
+	tests := []struct {
+		name         string
+		creds        Credentials
+		wantErr      error
+		wantStatus   int
+		wantLocation string
+	}{
+		{name: "accepted", creds: testCreds},
+		{name: "missing password", creds: Credentials{Email: testCreds.Email}, wantErr: ErrMissingCredentials},
+		{name: "wrong password", creds: Credentials{Email: testCreds.Email, Password: "wrong"}, wantErr: ErrLoginFailed, wantStatus: http.StatusFound, wantLocation: server.URL + "/login.php?error=1"},
+		{name: "no redirect", creds: Credentials{Email: "down@example.com", Password: "secret"}, wantErr: ErrLoginFailed, wantStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session, err := client.Login(context.Background(), tt.creds)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Login() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil {
+				if session == nil {
+					t.Error("Login() returned no session")
+				}
+				return
+			}
+			var loginErr *LoginError
+			if tt.wantStatus == 0 {
+				if errors.As(err, &loginErr) {
+					t.Errorf("Login() error = %#v, want no LoginError", loginErr)
+				}
+				return
+			}
+			if !errors.As(err, &loginErr) || loginErr.StatusCode != tt.wantStatus || loginErr.Location != tt.wantLocation {
+				t.Errorf("Login() error = %#v, want status %d and location %q", err, tt.wantStatus, tt.wantLocation)
+			}
+		})
+	}
+}
+
+func TestSessionBook(t *testing.T) {
+	server, requests := newTestSite(t)
+	session, err := newTestClient(t, server.URL).Login(context.Background(), testCreds)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	tests := []struct {
+		classID      string
+		wantErr      error
+		wantStatus   int
+		wantLocation string
+	}{
+		{classID: "booked"},
+		{classID: "plain"},
+		{classID: "full", wantErr: ErrRejected, wantStatus: http.StatusFound, wantLocation: server.URL + "/member-schedule.php?error=full"},
+		{classID: "expired", wantErr: ErrRejected, wantStatus: http.StatusOK},
+		{classID: "broken", wantErr: ErrRejected, wantStatus: http.StatusInternalServerError},
+		{classID: "", wantErr: ErrMissingClass},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.classID, func(t *testing.T) {
+			err := session.Book(context.Background(), "454", tt.classID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Book() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantStatus == 0 {
+				return
+			}
+			var actionErr *ActionError
+			if !errors.As(err, &actionErr) || actionErr.Action != "booking" || actionErr.StatusCode != tt.wantStatus || actionErr.Location != tt.wantLocation {
+				t.Errorf("Book() error = %#v, want status %d and location %q", err, tt.wantStatus, tt.wantLocation)
+			}
+		})
+	}
+
+	if got := requests(); !slices.Contains(got, "/_book_class.php?clubid=454&id=booked") {
+		t.Errorf("requests = %v, want the booking with club and class IDs", got)
+	}
+}
+
+func TestSessionCancel(t *testing.T) {
+	server, requests := newTestSite(t)
+	session, err := newTestClient(t, server.URL).Login(context.Background(), testCreds)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	if err := session.Cancel(context.Background(), "/_cancel_class.php?id=1"); err != nil {
+		t.Errorf("Cancel(relative link) = %v", err)
+	}
+	if err := session.Cancel(context.Background(), server.URL+"/_cancel_class.php?id=2"); err != nil {
+		t.Errorf("Cancel(absolute link) = %v", err)
+	}
+	if err := session.Cancel(context.Background(), ""); !errors.Is(err, ErrNoCancelLink) {
+		t.Errorf("Cancel(\"\") = %v, want ErrNoCancelLink", err)
+	}
+	if err := session.Cancel(context.Background(), "https://attacker.example/_cancel_class.php?id=3"); err == nil {
+		t.Error("Cancel() followed a link to another host")
+	}
+
+	want := []string{"/_cancel_class.php?id=1", "/_cancel_class.php?id=2"}
+	if got := requests(); !slices.Equal(got, want) {
+		t.Errorf("requests = %v, want %v", got, want)
+	}
+}
+
+func TestUninitialised(t *testing.T) {
+	ctx := context.Background()
+	var client *Client
+	var session *Session
+
+	if _, err := client.FetchClasses(ctx, testCreds, []Club{{ID: "454"}}); !errors.Is(err, ErrNotInitialised) {
+		t.Errorf("FetchClasses() = %v", err)
+	}
+	if _, err := client.Login(ctx, testCreds); !errors.Is(err, ErrNotInitialised) {
+		t.Errorf("Login() = %v", err)
+	}
+	if err := session.Book(ctx, "454", "1"); !errors.Is(err, ErrNotInitialised) {
+		t.Errorf("Book() = %v", err)
+	}
+	if err := (&Session{}).Cancel(ctx, "/cancel"); !errors.Is(err, ErrNotInitialised) {
+		t.Errorf("Cancel() = %v", err)
+	}
+	if _, err := newTestClient(t, "http://127.0.0.1").FetchClasses(ctx, testCreds, nil); !errors.Is(err, ErrNoClubs) {
+		t.Errorf("FetchClasses() without clubs = %v", err)
+	}
+}
+
+func TestErrorMessages(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{err: &LoginError{StatusCode: http.StatusOK}, want: "login failed: expected redirect, got status 200"},
+		{err: &LoginError{StatusCode: http.StatusFound, Location: "https://example.com/login.php"}, want: "login failed: unexpected redirect to https://example.com/login.php"},
+		{err: &ActionError{Action: "booking", StatusCode: http.StatusFound, Location: "https://example.com/x"}, want: "booking rejected, redirected to https://example.com/x"},
+		{err: &ActionError{Action: "cancel", StatusCode: http.StatusInternalServerError}, want: "cancel unexpected status 500"},
+		{err: &ActionError{Action: "booking", StatusCode: http.StatusOK, Reason: "session expired"}, want: "booking rejected: session expired"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestCancelHref(t *testing.T) {
+	page, _ := url.Parse("https://members.worldclass.ro/member-schedule.php")
+	req := &colly.Request{URL: page}
+
+	tests := map[string]string{
+		"_cancel_class.php?id=1":                         "https://members.worldclass.ro/_cancel_class.php?id=1",
+		" /_cancel_class.php?id=2 ":                      "https://members.worldclass.ro/_cancel_class.php?id=2",
+		"https://members.worldclass.ro/_cancel.php?id=3": "https://members.worldclass.ro/_cancel.php?id=3",
+		"#":                  "",
+		"#cancel-4":          "",
+		"JavaScript:void(0)": "",
+		"":                   "",
+	}
+	for href, want := range tests {
+		if got := cancelHref(req, href); got != want {
+			t.Errorf("cancelHref(%q) = %q, want %q", href, got, want)
+		}
+	}
+}
+
+func TestMergeClasses(t *testing.T) {
+	classes := []Class{
+		{ClubID: "454", ClassID: "1", Title: "PILATES"},
+		{ClubID: "454", ClassID: "2", Title: "SPINNING", Category: "Cardio"},
+		{ClubID: "454", ClassID: "1", Title: "PILATES", Category: "Mind & Body"},
+		{ClubID: "458", ClassID: "1", Title: "YOGA"},
+		{ClubID: "454", ClassID: "2", Title: "SPINNING", Category: "Indoor Cycling"},
+		{ClubID: "454", Title: "NO ID"},
+		{ClubID: "454", Title: "NO ID"},
+	}
+
+	got := mergeClasses(classes)
+	want := []Class{
+		{ClubID: "454", ClassID: "1", Title: "PILATES", Category: "Mind & Body"},
+		{ClubID: "454", ClassID: "2", Title: "SPINNING", Category: "Cardio"},
+		{ClubID: "458", ClassID: "1", Title: "YOGA"},
+		{ClubID: "454", Title: "NO ID"},
+		{ClubID: "454", Title: "NO ID"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("mergeClasses() = %+v, want %+v", got, want)
+	}
+}
+
+func TestResolveGroup(t *testing.T) {
+	available := []Group{{ID: "3", Name: "Cardio"}, {ID: "7", Name: "Mind & Body"}}
+
+	tests := []struct {
+		name    string
+		group   Group
+		want    Group
+		wantErr error
+	}{
+		{name: "id given", group: Group{ID: "9", Name: "Aqua"}, want: Group{ID: "9", Name: "Aqua"}},
+		{name: "name only", group: Group{Name: " mind & body "}, want: Group{ID: "7", Name: "Mind & Body"}},
+		{name: "unknown name", group: Group{Name: "Aqua"}, wantErr: ErrUnknownGroup},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveGroup(tt.group, available)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("resolveGroup() = %+v, %v; want %+v, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
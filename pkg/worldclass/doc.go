@@ -0,0 +1,23 @@
+// Package worldclass is a client for the World Class member site (https://members.worldclass.ro).
+//
+// A Client scrapes club schedules and opens authenticated Sessions that book and cancel classes:
+//
+//	client, err := worldclass.NewClient("https://members.worldclass.ro", nil)
+//	if err != nil {
+//		return err
+//	}
+//	classes, err := client.FetchClasses(ctx, creds, []worldclass.Club{{ID: "454", Name: "Park Lake"}})
+//	if err != nil {
+//		return err
+//	}
+//	session, err := client.Login(ctx, creds)
+//	if err != nil {
+//		return err
+//	}
+//	if err := session.Book(ctx, classes[0].ClubID, classes[0].ClassID); err != nil {
+//		return err
+//	}
+//
+// Failures are reported with the sentinel errors in this package (ErrLoginFailed, ErrRejected, ...), which
+// can be matched with errors.Is; LoginError and ActionError carry the HTTP details.
+package worldclass
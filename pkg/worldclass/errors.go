@@ -0,0 +1,67 @@
+package worldclass
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	// ErrNotInitialised is returned when a Client or Session was not created through its constructor.
+	ErrNotInitialised = errors.New("worldclass: client is not initialised")
+	// ErrMissingCredentials is returned when the email or password is empty.
+	ErrMissingCredentials = errors.New("worldclass: email and password are required")
+	// ErrNoClubs is returned when FetchClasses is called without clubs.
+	ErrNoClubs = errors.New("worldclass: at least one club is required")
+	// ErrNoClubSelector is returned by ListClubs when the schedule page has no club options, usually after a failed login.
+	ErrNoClubSelector = errors.New("worldclass: club selector not found on schedule page")
+	// ErrNoGroupSelector is returned when the schedule page has no group options, usually after a failed login.
+	ErrNoGroupSelector = errors.New("worldclass: group selector not found on schedule page")
+	// ErrUnknownGroup is returned when a group given by name is not offered by the group selector.
+	ErrUnknownGroup = errors.New("worldclass: unknown group")
+	// ErrMissingClass is returned when a club or class identifier is empty.
+	ErrMissingClass = errors.New("worldclass: clubID and classID are required")
+	// ErrNoCancelLink is returned by Session.Cancel when the class was scraped without a cancel link.
+	ErrNoCancelLink = errors.New("worldclass: class has no cancel link")
+	// ErrLoginFailed is wrapped by LoginError when the site refuses the credentials.
+	ErrLoginFailed = errors.New("worldclass: login failed")
+	// ErrRejected is wrapped by ActionError when the site refuses a booking or cancellation.
+	ErrRejected = errors.New("worldclass: request rejected")
+)
+
+// LoginError describes an unexpected login response.
+type LoginError struct {
+	StatusCode int
+	Location   string
+}
+
+func (e *LoginError) Error() string {
+	if e.StatusCode != http.StatusFound {
+		return fmt.Sprintf("login failed: expected redirect, got status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("login failed: unexpected redirect to %s", e.Location)
+}
+
+func (e *LoginError) Unwrap() error { return ErrLoginFailed }
+
+// ActionError describes a booking or cancellation the site did not accept.
+type ActionError struct {
+	// Action is "booking" or "cancel".
+	Action     string
+	StatusCode int
+	Location   string
+	// Reason explains a refusal the status code and location do not, such as an expired session.
+	Reason string
+}
+
+func (e *ActionError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("%s rejected: %s", e.Action, e.Reason)
+	}
+	if e.Location != "" {
+		return fmt.Sprintf("%s rejected, redirected to %s", e.Action, e.Location)
+	}
+	return fmt.Sprintf("%s unexpected status %d", e.Action, e.StatusCode)
+}
+
+func (e *ActionError) Unwrap() error { return ErrRejected }